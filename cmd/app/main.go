@@ -3,19 +3,29 @@ package main
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/wsppppp/manage-pull-request/internal/app"
 	"github.com/wsppppp/manage-pull-request/internal/config"
+	"github.com/wsppppp/manage-pull-request/internal/health"
+	"github.com/wsppppp/manage-pull-request/internal/logging"
+	"github.com/wsppppp/manage-pull-request/internal/metrics"
+	"github.com/wsppppp/manage-pull-request/internal/repository"
+	"github.com/wsppppp/manage-pull-request/internal/repository/memory"
 	"github.com/wsppppp/manage-pull-request/internal/repository/postgres"
+	"github.com/wsppppp/manage-pull-request/internal/repository/sqlite"
 	transport "github.com/wsppppp/manage-pull-request/internal/transport/http"
 	"github.com/wsppppp/manage-pull-request/pkg/database"
 )
@@ -26,28 +36,29 @@ func main() {
 
 	cfg := config.NewFromEnv()
 
-	log.Println("Running database migrations...")
-	migrationURL := cfg.MigrationURL() + "&x-migrations-table=schema_migrations"
-	m, err := migrate.New("file://migrations", migrationURL)
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
 	if err != nil {
-		log.Fatalf("failed to create migrate instance: %v", err)
+		fmt.Fprintf(os.Stderr, "failed to init logger: %v\n", err)
+		os.Exit(1)
 	}
+	slog.SetDefault(logger)
 
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		log.Fatalf("failed to apply migrations: %v", err)
-	}
-	log.Println("Migrations applied successfully")
-
-	dbPool, err := database.NewClient(ctx, cfg.DSN())
+	checker := health.NewChecker()
+	repo, closeRepo, err := newRepository(ctx, cfg, checker)
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		fatal("failed to init storage", err)
 	}
-	defer dbPool.Close()
+	defer closeRepo()
 
-	repo := postgres.New(dbPool)
 	service := app.New(repo)
-	handler := transport.NewHandler(service)
+	if cfg.AdminToken != "" {
+		if err := service.EnsureBootstrapToken(ctx, cfg.AdminToken); err != nil {
+			fatal("failed to create bootstrap admin token", err)
+		}
+	}
+	handler := transport.NewHandler(service, repo, checker)
 	router := handler.NewRouter()
+	go handler.CleanupIdempotencyKeys(ctx)
 
 	server := &http.Server{
 		Addr:    ":8080",
@@ -55,23 +66,89 @@ func main() {
 	}
 
 	go func() {
-		log.Println("Server is starting on port 8080...")
+		slog.Info("server is starting", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("ListenAndServe error: %v", err)
+			fatal("server failed", err)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+
+	// Сначала /readyz начинает отвечать 503, и балансировщик успевает вывести экземпляр из ротации,
+	// пока сервер еще обрабатывает запросы. Только после этого сервер перестает принимать соединения.
+	checker.Drain()
+	slog.Info("draining traffic before shutdown", "delay", cfg.DrainDelay)
+	time.Sleep(cfg.DrainDelay)
+	slog.Info("shutting down server")
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Fatalf("Server shutdown failed: %v", err)
+		fatal("server shutdown failed", err)
 	}
 
-	log.Println("Server gracefully stopped")
+	slog.Info("server gracefully stopped")
+}
+
+// fatal пишет ошибку в лог и завершает процесс.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// newRepository применяет миграции и создает хранилище, выбранное в STORAGE_DRIVER, а также добавляет
+// в checker проверки соединения с базой и версии ее схемы. Возвращаемая функция закрывает соединение с базой.
+func newRepository(ctx context.Context, cfg config.Config, checker *health.Checker) (repository.Repository, func(), error) {
+	switch cfg.StorageDriver {
+	case config.StorageMemory:
+		slog.Warn("using in-memory storage, data will be lost on restart")
+		return memory.New(), func() {}, nil
+	case config.StoragePostgres, config.StorageSQLite:
+	default:
+		return nil, nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+
+	slog.Info("running database migrations", "source", cfg.MigrationSource())
+	migrationURL := cfg.MigrationURL() + "&x-migrations-table=" + database.MigrationsTable
+	m, err := migrate.New(cfg.MigrationSource(), migrationURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return nil, nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	m.Close()
+	slog.Info("migrations applied")
+
+	latestMigration, err := health.LatestMigration(strings.TrimPrefix(cfg.MigrationSource(), "file://"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.StorageDriver == config.StorageSQLite {
+		db, err := database.NewSQLiteClient(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		metrics.Registry.MustRegister(collectors.NewDBStatsCollector(db, "sqlite"))
+		checker.Add("database", db.PingContext)
+		checker.Add("migrations", health.MigrationCheck(func(ctx context.Context) (uint, bool, error) {
+			return database.SQLiteMigrationVersion(ctx, db)
+		}, latestMigration))
+		return sqlite.New(db), func() { db.Close() }, nil
+	}
+
+	dbPool, err := database.NewClient(ctx, cfg.DSN())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	metrics.Registry.MustRegister(metrics.NewPoolCollector(dbPool))
+	checker.Add("database", dbPool.Ping)
+	checker.Add("migrations", health.MigrationCheck(func(ctx context.Context) (uint, bool, error) {
+		return database.MigrationVersion(ctx, dbPool)
+	}, latestMigration))
+	return postgres.New(dbPool), dbPool.Close, nil
 }
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// AddAbsence планирует отсутствие пользователя: с startsAt до endsAt он не выбирается ревьюером.
+// Возвращает ErrInvalidAbsence, если период пустой, и ErrUserNotFound, если пользователя нет.
+func (s *Service) AddAbsence(ctx context.Context, userID string, startsAt, endsAt time.Time, reason string) (domain.Absence, error) {
+	if err := s.requireSelfOrLead(ctx, userID); err != nil {
+		return domain.Absence{}, err
+	}
+	if !endsAt.After(startsAt) {
+		return domain.Absence{}, ErrInvalidAbsence
+	}
+	return s.repo.CreateAbsence(ctx, domain.Absence{
+		UserID:   userID,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+		Reason:   strings.TrimSpace(reason),
+	})
+}
+
+// GetAbsences возвращает отсутствия пользователя в порядке начала. Возвращает ErrUserNotFound, если пользователя нет.
+func (s *Service) GetAbsences(ctx context.Context, userID string) ([]domain.Absence, error) {
+	if _, err := s.repo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAbsences(ctx, userID)
+}
+
+// CancelAbsence отменяет отсутствие и возвращает его. Возвращает ErrAbsenceNotFound, если его нет.
+func (s *Service) CancelAbsence(ctx context.Context, absenceID int64) (domain.Absence, error) {
+	absence, err := s.repo.GetAbsence(ctx, absenceID)
+	if err != nil {
+		return domain.Absence{}, err
+	}
+	if err := s.requireSelfOrLead(ctx, absence.UserID); err != nil {
+		return domain.Absence{}, err
+	}
+	return s.repo.DeleteAbsence(ctx, absenceID)
+}
+
+// availableCandidates убирает из кандидатов тех, кто сейчас отсутствует.
+func (s *Service) availableCandidates(ctx context.Context, candidates []domain.User) ([]domain.User, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.ID
+	}
+	absent, err := s.repo.AbsentUsers(ctx, ids, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]domain.User, 0, len(candidates))
+	for _, c := range candidates {
+		if !absent[c.ID] {
+			available = append(available, c)
+		}
+	}
+	return available, nil
+}
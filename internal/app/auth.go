@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// Правила доступа. Читать данные может любой аутентифицированный токен, изменения проверяются так:
+//   - admin может всё;
+//   - team-lead управляет командами, в которых состоит его пользователь, и PR этих команд;
+//   - member меняет только свои данные и свои PR, отправляет ревью за себя и отклоняет ревью своих PR;
+//   - bot создает PR от имени любого автора, меняет их статус и заменяет ревьюеров, но не сливает PR.
+
+type principalKey struct{}
+
+// WithPrincipal сохраняет в контексте токен, которым аутентифицирован запрос.
+func WithPrincipal(ctx context.Context, token domain.APIToken) context.Context {
+	return context.WithValue(ctx, principalKey{}, token)
+}
+
+// PrincipalFromContext возвращает токен, которым аутентифицирован запрос.
+func PrincipalFromContext(ctx context.Context) (domain.APIToken, bool) {
+	token, ok := ctx.Value(principalKey{}).(domain.APIToken)
+	return token, ok
+}
+
+// principal возвращает токен запроса или ErrUnauthenticated, если запрос не аутентифицирован.
+func principal(ctx context.Context) (domain.APIToken, error) {
+	token, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return domain.APIToken{}, ErrUnauthenticated
+	}
+	return token, nil
+}
+
+func forbidden(reason string) error {
+	return fmt.Errorf("%w: %s", ErrForbidden, reason)
+}
+
+// requireAdmin разрешает операцию только администратору.
+func (s *Service) requireAdmin(ctx context.Context, reason string) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Role != domain.RoleAdmin {
+		return forbidden(reason)
+	}
+	return nil
+}
+
+// requireTeamLead разрешает операцию с командой администратору и руководителю этой команды.
+func (s *Service) requireTeamLead(ctx context.Context, teamName string) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Role == domain.RoleAdmin {
+		return nil
+	}
+	lead, err := s.leadsAny(ctx, p, []string{teamName})
+	if err != nil || lead {
+		return err
+	}
+	return forbidden("only admins and the team lead can manage the team")
+}
+
+// requireSelf разрешает операцию с пользователем только ему самому и администратору.
+func (s *Service) requireSelf(ctx context.Context, userID, reason string) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Role != domain.RoleAdmin && (p.UserID == "" || p.UserID != userID) {
+		return forbidden(reason)
+	}
+	return nil
+}
+
+// requireSelfOrLead разрешает операцию с пользователем ему самому, руководителю одной из его команд
+// и администратору. Возвращает ErrUserNotFound, если пользователя нет.
+func (s *Service) requireSelfOrLead(ctx context.Context, userID string) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Role == domain.RoleAdmin || (p.UserID != "" && p.UserID == userID) {
+		return nil
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	lead, err := s.leadsAny(ctx, p, user.Teams)
+	if err != nil || lead {
+		return err
+	}
+	return forbidden("only the user, their team lead and admins can manage the user's absences")
+}
+
+// requireAuthor разрешает создать PR от имени authorID самому автору, ботам и администраторам.
+func (s *Service) requireAuthor(ctx context.Context, authorID string) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Role == domain.RoleAdmin || p.Role == domain.RoleBot || (p.UserID != "" && p.UserID == authorID) {
+		return nil
+	}
+	return forbidden("pull requests can only be created on behalf of the token's user")
+}
+
+// requirePRAccess разрешает изменить PR его автору, руководителю команды PR, администратору,
+// а при allowBot - и ботам.
+func (s *Service) requirePRAccess(ctx context.Context, pr *domain.PullRequest, allowBot bool, reason string) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Role == domain.RoleAdmin || (allowBot && p.Role == domain.RoleBot) || (p.UserID != "" && p.UserID == pr.AuthorID) {
+		return nil
+	}
+
+	teamName := pr.TeamName
+	if teamName == "" {
+		// PR без команды относится к основной команде автора, как в prTeam.
+		author, err := s.repo.GetUserByID(ctx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+		teamName = author.TeamName
+	}
+	lead, err := s.leadsAny(ctx, p, []string{teamName})
+	if err != nil || lead {
+		return err
+	}
+	return forbidden(reason)
+}
+
+// leadsAny сообщает, руководит ли владелец токена хотя бы одной из команд teamNames:
+// у него роль team-lead, и его пользователь состоит в команде.
+func (s *Service) leadsAny(ctx context.Context, p domain.APIToken, teamNames []string) (bool, error) {
+	if p.Role != domain.RoleTeamLead || p.UserID == "" {
+		return false, nil
+	}
+	lead, err := s.repo.GetUserByID(ctx, p.UserID)
+	if err != nil {
+		return false, err
+	}
+	for _, teamName := range teamNames {
+		if teamName != "" && lead.InTeam(teamName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
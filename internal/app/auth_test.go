@@ -0,0 +1,162 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+func TestAccessRules(t *testing.T) {
+	var (
+		lead     = as(domain.RoleTeamLead, "lead")
+		author   = as(domain.RoleMember, "author")
+		reviewer = as(domain.RoleMember, "b")
+		bot      = as(domain.RoleBot, "")
+		outsider = as(domain.RoleTeamLead, "other-lead")
+	)
+	// Команда backend: руководитель lead, автор PR author и ревьюер b - единственный с ненулевым весом.
+	// В команде other свой руководитель, который не управляет backend.
+	newAccessService := func(t *testing.T) (*app.Service, *domain.PullRequest) {
+		svc := newService(t,
+			team("backend", domain.StrategyWeighted, 1, member("lead", 0), member("author", 0), member("b", 1)),
+			team("other", domain.StrategyRandom, 1, member("other-lead", 1)),
+		)
+		pr, err := svc.CreatePullRequest(adminCtx, "pr", "feature", "author", "", nil, false)
+		if err != nil {
+			t.Fatalf("create pr: %v", err)
+		}
+		return svc, pr
+	}
+
+	settings := app.TeamSettingsUpdate{MaxReviewers: new(int)}
+	*settings.MaxReviewers = 3
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		op      func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error
+		wantErr error
+	}{
+		{"unauthenticated", context.Background(), func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.SetUserActivity(ctx, "b", false)
+			return err
+		}, app.ErrUnauthenticated},
+		{"only admins create teams", lead, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.CreateTeam(ctx, team("new", domain.StrategyRandom, 1))
+			return err
+		}, app.ErrForbidden},
+		{"lead updates own team", lead, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.UpdateTeamSettings(ctx, "backend", settings)
+			return err
+		}, nil},
+		{"lead of another team", outsider, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.UpdateTeamSettings(ctx, "backend", settings)
+			return err
+		}, app.ErrForbidden},
+		{"member cannot manage team", author, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.UpdateTeamSettings(ctx, "backend", settings)
+			return err
+		}, app.ErrForbidden},
+		{"member changes own activity", reviewer, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.SetUserActivity(ctx, "b", false)
+			return err
+		}, nil},
+		{"member cannot change others' activity", author, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.SetUserActivity(ctx, "b", false)
+			return err
+		}, app.ErrForbidden},
+		{"lead adds absence of team member", lead, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.AddAbsence(ctx, "b", time.Now(), tomorrow, "vacation")
+			return err
+		}, nil},
+		{"member cannot add others' absence", author, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.AddAbsence(ctx, "b", time.Now(), tomorrow, "vacation")
+			return err
+		}, app.ErrForbidden},
+		{"member creates own pull request", author, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.CreatePullRequest(ctx, "pr-own", "feature", "author", "", nil, false)
+			return err
+		}, nil},
+		{"member cannot create pull request for others", reviewer, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.CreatePullRequest(ctx, "pr-other", "feature", "author", "", nil, false)
+			return err
+		}, app.ErrForbidden},
+		{"bot creates pull request for anyone", bot, func(ctx context.Context, svc *app.Service, _ *domain.PullRequest) error {
+			_, err := svc.CreatePullRequest(ctx, "pr-bot", "feature", "author", "", nil, false)
+			return err
+		}, nil},
+		{"bot closes pull request", bot, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.ClosePullRequest(ctx, pr.ID, 0)
+			return err
+		}, nil},
+		{"bot cannot merge", bot, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.MergePullRequest(ctx, pr.ID, false, 0)
+			return err
+		}, app.ErrForbidden},
+		{"author merges", author, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.MergePullRequest(ctx, pr.ID, false, 0)
+			return err
+		}, nil},
+		{"lead merges team pull request", lead, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.MergePullRequest(ctx, pr.ID, false, 0)
+			return err
+		}, nil},
+		{"lead of another team cannot merge", outsider, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.MergePullRequest(ctx, pr.ID, false, 0)
+			return err
+		}, app.ErrForbidden},
+		{"reviewer cannot merge", reviewer, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.MergePullRequest(ctx, pr.ID, false, 0)
+			return err
+		}, app.ErrForbidden},
+		{"only admins force merge", lead, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.MergePullRequest(ctx, pr.ID, true, 0)
+			return err
+		}, app.ErrForbidden},
+		{"reviewer submits own review", reviewer, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.SubmitReview(ctx, pr.ID, "b", domain.ReviewApproved, 0)
+			return err
+		}, nil},
+		{"author cannot approve for the reviewer", author, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.SubmitReview(ctx, pr.ID, "b", domain.ReviewApproved, 0)
+			return err
+		}, app.ErrForbidden},
+		{"author dismisses the review", author, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.SubmitReview(ctx, pr.ID, "b", domain.ReviewDismissed, 0)
+			return err
+		}, nil},
+		{"lead dismisses the review", lead, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.SubmitReview(ctx, pr.ID, "b", domain.ReviewDismissed, 0)
+			return err
+		}, nil},
+		{"reviewer dismisses own review", reviewer, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.SubmitReview(ctx, pr.ID, "b", domain.ReviewDismissed, 0)
+			return err
+		}, nil},
+		{"lead of another team cannot dismiss", outsider, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.SubmitReview(ctx, pr.ID, "b", domain.ReviewDismissed, 0)
+			return err
+		}, app.ErrForbidden},
+		{"lead cannot approve for the reviewer", lead, func(ctx context.Context, svc *app.Service, pr *domain.PullRequest) error {
+			_, err := svc.SubmitReview(ctx, pr.ID, "b", domain.ReviewApproved, 0)
+			return err
+		}, app.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, pr := newAccessService(t)
+			err := tt.op(tt.ctx, svc, pr)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("got %v, want no error", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
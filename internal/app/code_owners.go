@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// codeOwnerDetails - пояснение в журнале PR для ревьюера, назначенного как владелец измененных файлов.
+const codeOwnerDetails = "code_owner"
+
+// SetCodeOwners заменяет правила владения кодом команды и возвращает команду с новыми правилами.
+// Возвращает ErrNotFound, если команды нет, и ErrInvalidCodeOwner, если шаблон правила некорректен
+// или правило ссылается на несуществующего пользователя.
+func (s *Service) SetCodeOwners(ctx context.Context, teamName string, rules []domain.CodeOwnerRule) (domain.Team, error) {
+	if err := s.requireTeamLead(ctx, teamName); err != nil {
+		return domain.Team{}, err
+	}
+	if err := validateCodeOwners(rules); err != nil {
+		return domain.Team{}, err
+	}
+	if err := s.repo.SetCodeOwners(ctx, teamName, rules); err != nil {
+		return domain.Team{}, err
+	}
+	return s.repo.GetTeamByName(ctx, teamName)
+}
+
+// validateCodeOwners проверяет синтаксис шаблонов правил.
+func validateCodeOwners(rules []domain.CodeOwnerRule) error {
+	for i, rule := range rules {
+		if !rule.Valid() {
+			return fmt.Errorf("%w: rule %d has invalid pattern %q", ErrInvalidCodeOwner, i, rule.Pattern)
+		}
+	}
+	return nil
+}
+
+// normalizeChangedFiles убирает пустые пути и повторы, сохраняя порядок.
+func normalizeChangedFiles(files []string) []string {
+	normalized := make([]string, 0, len(files))
+	seen := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+		if _, ok := seen[file]; !ok {
+			seen[file] = struct{}{}
+			normalized = append(normalized, file)
+		}
+	}
+	return normalized
+}
+
+// assignReviewers выбирает до n ревьюеров PR: сначала владельцев измененных файлов по правилам команды,
+// затем оставшиеся места заполняет обычный выбор selectReviewers.
+// Владельцы проходят те же фильтры, что и остальные кандидаты: активны, не автор и не отсутствуют.
+func (s *Service) assignReviewers(ctx context.Context, team domain.Team, authorID string, changedFiles []string, n int) (owners, others []string, err error) {
+	owners, err = s.fileOwners(ctx, team, authorID, changedFiles, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	others, err = s.selectReviewers(ctx, team, authorID, owners, n-len(owners))
+	if err != nil {
+		return nil, nil, err
+	}
+	return owners, others, nil
+}
+
+// fileOwners возвращает до n доступных владельцев измененных файлов в порядке совпадения правил.
+func (s *Service) fileOwners(ctx context.Context, team domain.Team, authorID string, changedFiles []string, n int) ([]string, error) {
+	ownerIDs := domain.CodeOwners(team.CodeOwners, changedFiles)
+	if len(ownerIDs) == 0 {
+		return []string{}, nil
+	}
+
+	users := make([]domain.User, 0, len(ownerIDs))
+	for _, userID := range ownerIDs {
+		user, err := s.repo.GetUserByID(ctx, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	candidates, err := s.availableCandidates(ctx, reviewerCandidates(users, authorID, nil))
+	if err != nil {
+		return nil, err
+	}
+	return firstIDs(candidates, n), nil
+}
+
+// ownerEvents создает события назначения ревьюеров, помечая владельцев кода.
+func ownerEvents(ctx context.Context, prID string, owners, others []string) []domain.PREvent {
+	events := reviewerEvents(ctx, prID, domain.EventReviewerAssigned, owners)
+	for i := range events {
+		events[i].Details = codeOwnerDetails
+	}
+	return append(events, reviewerEvents(ctx, prID, domain.EventReviewerAssigned, others)...)
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/metrics"
+)
+
+// maxDeactivateAttempts - сколько раз пересоставляем замены, если PR меняются параллельно с деактивацией.
+const maxDeactivateAttempts = 3
+
+// deactivatedDetails - пояснение в журнале PR для замены деактивированного ревьюера.
+const deactivatedDetails = "deactivated"
+
+// UnreplacedReviewer - ревьюер, для которого не нашлось замены; он остается назначенным на PR.
+type UnreplacedReviewer struct {
+	PullRequestID string
+	ReviewerID    string
+}
+
+// Deactivation - результат деактивации пользователей: на каких открытых PR их ревью переданы другим,
+// а где кандидата не нашлось.
+type Deactivation struct {
+	UserIDs     []string
+	Reassigned  []ReviewerReassignment
+	NoCandidate []UnreplacedReviewer
+}
+
+// DeactivateUser снимает с пользователя флаг активности и заменяет его на всех открытых PR,
+// где он назначен ревьюером, по тем же правилам выбора кандидатов, что и ReassignReviewer.
+// Возвращает ErrUserNotFound, если пользователя нет.
+func (s *Service) DeactivateUser(ctx context.Context, userID string) (*Deactivation, error) {
+	if err := s.requireSelf(ctx, userID, "only admins can deactivate other users"); err != nil {
+		return nil, err
+	}
+	return s.deactivate(ctx, []string{userID})
+}
+
+// DeactivateTeam деактивирует всех участников команды так же, как DeactivateUser.
+// Участники не становятся кандидатами на замену друг друга. Возвращает ErrNotFound, если команды нет.
+func (s *Service) DeactivateTeam(ctx context.Context, teamName string) (*Deactivation, error) {
+	if err := s.requireAdmin(ctx, "only admins can deactivate teams"); err != nil {
+		return nil, err
+	}
+	team, err := s.repo.GetTeamByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		userIDs = append(userIDs, member.ID)
+	}
+	if len(userIDs) == 0 {
+		return &Deactivation{UserIDs: userIDs, Reassigned: []ReviewerReassignment{}, NoCandidate: []UnreplacedReviewer{}}, nil
+	}
+	return s.deactivate(ctx, userIDs)
+}
+
+// deactivate составляет замены и применяет их вместе с деактивацией в одной транзакции.
+// Повторяющиеся идентификаторы учитываются один раз. Если PR успел измениться, замены составляются заново.
+func (s *Service) deactivate(ctx context.Context, userIDs []string) (*Deactivation, error) {
+	userIDs = uniqueUserIDs(userIDs)
+	for attempt := 1; ; attempt++ {
+		report, updates, err := s.planDeactivation(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.repo.DeactivateUsers(ctx, userIDs, updates)
+		if errors.Is(err, ErrVersionConflict) && attempt < maxDeactivateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		metrics.ReviewerReassignments.WithLabelValues(metrics.ReasonDeactivated).Add(float64(len(report.Reassigned)))
+		metrics.NoCandidate.WithLabelValues(metrics.ReasonDeactivated).Add(float64(len(report.NoCandidate)))
+		return report, nil
+	}
+}
+
+// uniqueUserIDs убирает повторы идентификаторов, сохраняя порядок.
+func uniqueUserIDs(userIDs []string) []string {
+	unique := make([]string, 0, len(userIDs))
+	seen := make(map[string]struct{}, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := seen[userID]; !ok {
+			seen[userID] = struct{}{}
+			unique = append(unique, userID)
+		}
+	}
+	return unique
+}
+
+// planDeactivation подбирает замену каждому деактивируемому ревьюеру на открытых PR.
+func (s *Service) planDeactivation(ctx context.Context, userIDs []string) (*Deactivation, []domain.ReviewerUpdate, error) {
+	report := &Deactivation{UserIDs: userIDs, Reassigned: []ReviewerReassignment{}, NoCandidate: []UnreplacedReviewer{}}
+
+	// Собираем открытые PR деактивируемых ревьюеров без повторов.
+	var prs []*domain.PullRequest
+	seen := make(map[string]struct{})
+	for _, userID := range userIDs {
+		if _, err := s.repo.GetUserByID(ctx, userID); err != nil {
+			return nil, nil, err
+		}
+		open, err := s.openReviews(ctx, userID, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, pr := range open {
+			if _, ok := seen[pr.ID]; !ok {
+				seen[pr.ID] = struct{}{}
+				prs = append(prs, pr)
+			}
+		}
+	}
+
+	updates := make([]domain.ReviewerUpdate, 0, len(prs))
+	for _, pr := range prs {
+		team, err := s.prTeam(ctx, pr)
+		if errors.Is(err, ErrAuthorNotFound) {
+			// Без команды кандидатов нет, но деактивацию это не блокирует.
+			team, err = domain.Team{}, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Кандидатами не могут быть ни текущие ревьюеры, ни сами деактивируемые пользователи.
+		excluded := append(append([]string{}, pr.AssignedReviewers...), userIDs...)
+		reviewers := append([]string{}, pr.AssignedReviewers...)
+		var events []domain.PREvent
+		for i, reviewerID := range pr.AssignedReviewers {
+			if !slices.Contains(userIDs, reviewerID) {
+				continue
+			}
+			selected, err := s.selectReviewers(ctx, team, pr.AuthorID, excluded, 1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(selected) == 0 {
+				report.NoCandidate = append(report.NoCandidate, UnreplacedReviewer{PullRequestID: pr.ID, ReviewerID: reviewerID})
+				continue
+			}
+
+			newReviewerID := selected[0]
+			reviewers[i] = newReviewerID
+			excluded = append(excluded, newReviewerID)
+
+			event := newEvent(ctx, pr.ID, domain.EventReviewerReplaced)
+			event.OldReviewerID = reviewerID
+			event.ReviewerID = newReviewerID
+			event.Details = deactivatedDetails
+			events = append(events, event)
+			report.Reassigned = append(report.Reassigned, ReviewerReassignment{
+				PullRequestID: pr.ID, OldReviewerID: reviewerID, NewReviewerID: newReviewerID,
+			})
+		}
+		if len(events) > 0 {
+			updates = append(updates, domain.ReviewerUpdate{
+				PullRequestID: pr.ID, Version: pr.Version, Reviewers: reviewers, Events: events,
+			})
+		}
+	}
+	return report, updates, nil
+}
@@ -3,6 +3,9 @@ package app
 import (
 	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
 )
 
 // Определяем кастомные ошибки для бизнес-логики для удобной обработки в HTTP-слое.
@@ -12,9 +15,29 @@ var (
 	ErrPRExists            = errors.New("pull request already exists")
 	ErrPRNotFound          = errors.New("pull request not found")
 	ErrPRMerged            = errors.New("pull request is already merged")
+	ErrPRNotOpen           = errors.New("pull request is not open")
 	ErrNotFound            = errors.New("not found")
 	ErrReviewerNotAssigned = fmt.Errorf("reviewer is not assigned to this pull request")
 	ErrNoCandidates        = fmt.Errorf("no active replacement candidate in team")
+	ErrUnknownStrategy     = errors.New("unknown reviewer strategy")
+	ErrInvalidTeamSettings = errors.New("invalid team settings")
+	ErrInvalidReviewWeight = errors.New("review_weight must not be negative")
+	ErrInvalidReviewState  = errors.New("invalid review state")
+	ErrVersionConflict     = errors.New("pull request was modified concurrently")
+	ErrVersionMismatch     = fmt.Errorf("%w: version does not match If-Match", ErrVersionConflict)
+	ErrNotTeamMember       = errors.New("user is not a member of the team")
+	ErrInvalidTeamName     = errors.New("team name must not be empty")
+	ErrTeamHasOpenPRs      = errors.New("team members have open or draft pull requests")
+	ErrInvalidReviewerPool = errors.New("invalid reviewer pool")
+	ErrAbsenceNotFound     = errors.New("absence not found")
+	ErrInvalidAbsence      = errors.New("absence must end after it starts")
+	ErrInvalidCodeOwner    = errors.New("invalid code owner rule")
+	ErrInvalidListFilter   = errors.New("invalid list filter")
+	ErrInvalidStatsPeriod  = errors.New("stats period must end after it starts")
+	ErrTokenNotFound       = errors.New("api token not found")
+	ErrInvalidAPIToken     = errors.New("invalid api token")
+	ErrUnauthenticated     = errors.New("api token is missing, unknown, revoked or expired")
+	ErrForbidden           = errors.New("forbidden")
 )
 
 type ErrTeamExists struct {
@@ -24,3 +47,30 @@ type ErrTeamExists struct {
 func (e *ErrTeamExists) Error() string {
 	return fmt.Sprintf("team %q already exists", e.TeamName)
 }
+
+type ErrInvalidReviewTransition struct {
+	From domain.ReviewState
+	To   domain.ReviewState
+}
+
+func (e *ErrInvalidReviewTransition) Error() string {
+	return fmt.Sprintf("cannot change review state from %s to %s", e.From, e.To)
+}
+
+type ErrInvalidTransition struct {
+	From domain.PRStatus
+	To   domain.PRStatus
+}
+
+func (e *ErrInvalidTransition) Error() string {
+	return fmt.Sprintf("cannot change pull request status from %s to %s", e.From, e.To)
+}
+
+// ErrMergeBlocked возвращается, когда PR не удовлетворяет политике слияния команды.
+type ErrMergeBlocked struct {
+	Reasons []string
+}
+
+func (e *ErrMergeBlocked) Error() string {
+	return "merge blocked: " + strings.Join(e.Reasons, "; ")
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+type actorKey struct{}
+
+// WithActor сохраняет в контексте ID пользователя, выполняющего запрос.
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// ActorFromContext возвращает ID пользователя, выполняющего запрос, или пустую строку.
+func ActorFromContext(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorKey{}).(string)
+	return actorID
+}
+
+// newEvent создает событие PR от имени пользователя из контекста.
+func newEvent(ctx context.Context, prID string, eventType domain.PREventType) domain.PREvent {
+	return domain.PREvent{
+		PullRequestID: prID,
+		Type:          eventType,
+		ActorID:       ActorFromContext(ctx),
+		CreatedAt:     time.Now(),
+	}
+}
+
+// reviewerEvents создает по событию eventType для каждого ревьюера.
+func reviewerEvents(ctx context.Context, prID string, eventType domain.PREventType, reviewers []string) []domain.PREvent {
+	events := make([]domain.PREvent, 0, len(reviewers))
+	for _, reviewerID := range reviewers {
+		event := newEvent(ctx, prID, eventType)
+		event.ReviewerID = reviewerID
+		events = append(events, event)
+	}
+	return events
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/metrics"
+)
+
+// maxRemoveAttempts - сколько раз пересоставляем замены, если PR меняются параллельно с исключением участника.
+const maxRemoveAttempts = 3
+
+// leftTeamDetails - пояснение в журнале PR для ревьюеров, покинувших команду.
+const leftTeamDetails = "left_team"
+
+// ReviewerReassignment описывает замену ревьюера на PR.
+type ReviewerReassignment struct {
+	PullRequestID string
+	OldReviewerID string
+	NewReviewerID string
+}
+
+// MemberRemoval - результат ухода участника из команды: на каких PR его заменили,
+// а какие остались без замены и теперь недоукомплектованы ревьюерами.
+type MemberRemoval struct {
+	TeamName      string
+	UserID        string
+	Reassigned    []ReviewerReassignment
+	UnderReviewed []string
+}
+
+// AddTeamMember добавляет пользователя в команду. Пользователь остается и во всех своих прежних командах.
+// Возвращает ErrNotFound, если команды нет, и ErrInvalidReviewWeight, если вес участника отрицательный.
+func (s *Service) AddTeamMember(ctx context.Context, teamName string, member domain.User) (domain.Team, error) {
+	if err := s.requireTeamLead(ctx, teamName); err != nil {
+		return domain.Team{}, err
+	}
+	if err := validateReviewWeight(member); err != nil {
+		return domain.Team{}, err
+	}
+	if err := s.repo.AddTeamMember(ctx, teamName, member); err != nil {
+		return domain.Team{}, err
+	}
+	return s.repo.GetTeamByName(ctx, teamName)
+}
+
+// RemoveTeamMember исключает пользователя из команды. Его ревью, по которым он ещё не принял решение,
+// на открытых PR команды передаются другим участникам по стратегии команды.
+// Если замены нет, ревьюер просто снимается, и PR становится недоукомплектованным (under_reviewed).
+// Исключение и замены применяются в одной транзакции; если PR успел измениться, замены составляются заново.
+// Возвращает ErrNotFound, если команды нет, и ErrNotTeamMember, если пользователь в ней не состоит.
+func (s *Service) RemoveTeamMember(ctx context.Context, teamName, userID string) (*MemberRemoval, error) {
+	if err := s.requireTeamLead(ctx, teamName); err != nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		removal, updates, err := s.planRemoval(ctx, teamName, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.repo.RemoveTeamMember(ctx, teamName, userID, updates)
+		if errors.Is(err, ErrVersionConflict) && attempt < maxRemoveAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		metrics.ReviewerReassignments.WithLabelValues(metrics.ReasonLeftTeam).Add(float64(len(removal.Reassigned)))
+		metrics.NoCandidate.WithLabelValues(metrics.ReasonLeftTeam).Add(float64(len(removal.UnderReviewed)))
+		return removal, nil
+	}
+}
+
+// RenameTeam переименовывает команду вместе со ссылками участников и PR на неё.
+// Возвращает ErrNotFound, если команды нет, и ErrTeamExists, если новое имя занято.
+func (s *Service) RenameTeam(ctx context.Context, teamName, newName string) (domain.Team, error) {
+	if err := s.requireAdmin(ctx, "only admins can rename teams"); err != nil {
+		return domain.Team{}, err
+	}
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return domain.Team{}, ErrInvalidTeamName
+	}
+	if newName != teamName {
+		if err := s.repo.RenameTeam(ctx, teamName, newName); err != nil {
+			return domain.Team{}, err
+		}
+	}
+	return s.repo.GetTeamByName(ctx, newName)
+}
+
+// DeleteTeam удаляет команду. Участники остаются в своих остальных командах.
+// Возвращает ErrNotFound, если команды нет, и ErrTeamHasOpenPRs, пока в команде есть открытые PR или черновики.
+func (s *Service) DeleteTeam(ctx context.Context, teamName string) error {
+	if err := s.requireAdmin(ctx, "only admins can delete teams"); err != nil {
+		return err
+	}
+	return s.repo.DeleteTeam(ctx, teamName)
+}
+
+// planRemoval подбирает замену уходящему из команды пользователю на открытых PR команды,
+// где он ещё не принял решение. Если кандидатов нет, ревьюер просто снимается.
+func (s *Service) planRemoval(ctx context.Context, teamName, userID string) (*MemberRemoval, []domain.ReviewerUpdate, error) {
+	removal := &MemberRemoval{
+		TeamName:      teamName,
+		UserID:        userID,
+		Reassigned:    []ReviewerReassignment{},
+		UnderReviewed: []string{},
+	}
+
+	team, err := s.repo.GetTeamByName(ctx, teamName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prs, err := s.openReviews(ctx, userID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	updates := make([]domain.ReviewerUpdate, 0, len(prs))
+	for _, pr := range prs {
+		if pr.TeamName != teamName || pr.Status != domain.StatusOpen || pr.ReviewStates[userID] != domain.ReviewPending {
+			continue
+		}
+		selected, err := s.selectReviewers(ctx, team, pr.AuthorID, pr.AssignedReviewers, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		reviewers := make([]string, 0, len(pr.AssignedReviewers))
+		for _, reviewerID := range pr.AssignedReviewers {
+			if reviewerID != userID {
+				reviewers = append(reviewers, reviewerID)
+			}
+		}
+
+		event := newEvent(ctx, pr.ID, domain.EventReviewerRemoved)
+		event.ReviewerID = userID
+		if len(selected) > 0 {
+			reviewers = append(reviewers, selected[0])
+			event.Type = domain.EventReviewerReplaced
+			event.OldReviewerID = userID
+			event.ReviewerID = selected[0]
+			removal.Reassigned = append(removal.Reassigned, ReviewerReassignment{
+				PullRequestID: pr.ID, OldReviewerID: userID, NewReviewerID: selected[0],
+			})
+		} else {
+			removal.UnderReviewed = append(removal.UnderReviewed, pr.ID)
+		}
+		event.Details = leftTeamDetails
+
+		updates = append(updates, domain.ReviewerUpdate{
+			PullRequestID: pr.ID, Version: pr.Version, Reviewers: reviewers, Events: []domain.PREvent{event},
+		})
+	}
+	return removal, updates, nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// unmetMergeConditions возвращает невыполненные условия политики слияния команды.
+// Пустой результат означает, что PR можно слить. Ревьюеры в состоянии DISMISSED не участвуют в решении:
+// их одобрение не требуется и не засчитывается.
+func unmetMergeConditions(settings domain.TeamSettings, pr *domain.PullRequest) []string {
+	approvals := 0
+	var unmet []string
+	for _, reviewerID := range pr.AssignedReviewers {
+		switch pr.ReviewStates[reviewerID] {
+		case domain.ReviewApproved:
+			approvals++
+		case domain.ReviewChangesRequested:
+			if settings.MergePolicy != domain.MergePolicyNone {
+				unmet = append(unmet, fmt.Sprintf("reviewer %s requested changes", reviewerID))
+			}
+		case domain.ReviewPending:
+			if settings.MergePolicy == domain.MergePolicyAllApproved {
+				unmet = append(unmet, fmt.Sprintf("reviewer %s has not approved", reviewerID))
+			}
+		case domain.ReviewDismissed:
+			// Ревью отклонено - одобрения этого ревьюера не ждем.
+		}
+	}
+
+	switch settings.MergePolicy {
+	case domain.MergePolicyAllApproved:
+		if approvals == 0 {
+			unmet = append(unmet, "no approvals from assigned reviewers")
+		}
+	case domain.MergePolicyMinApprovals:
+		if approvals < settings.RequiredApprovals {
+			unmet = append(unmet, fmt.Sprintf("%d of %d required approvals", approvals, settings.RequiredApprovals))
+		}
+	}
+	return unmet
+}
+
+// validMergePolicy сообщает, что политика слияния известна.
+func validMergePolicy(policy domain.MergePolicy) bool {
+	switch policy {
+	case domain.MergePolicyNone, domain.MergePolicyAllApproved, domain.MergePolicyMinApprovals:
+		return true
+	}
+	return false
+}
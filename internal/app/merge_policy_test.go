@@ -0,0 +1,62 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+func TestUnmetMergeConditions(t *testing.T) {
+	policy := func(mergePolicy domain.MergePolicy, required int) domain.TeamSettings {
+		settings := domain.DefaultTeamSettings()
+		settings.MergePolicy = mergePolicy
+		settings.RequiredApprovals = required
+		return settings
+	}
+	pr := func(states ...domain.ReviewState) *domain.PullRequest {
+		reviewers := []string{"a", "b", "c"}[:len(states)]
+		reviewStates := make(map[string]domain.ReviewState, len(states))
+		for i, state := range states {
+			reviewStates[reviewers[i]] = state
+		}
+		return &domain.PullRequest{AssignedReviewers: reviewers, ReviewStates: reviewStates}
+	}
+
+	tests := []struct {
+		name     string
+		settings domain.TeamSettings
+		pr       *domain.PullRequest
+		want     []string
+	}{
+		{"none ignores reviews", policy(domain.MergePolicyNone, 0),
+			pr(domain.ReviewChangesRequested, domain.ReviewPending), nil},
+		{"all approved", policy(domain.MergePolicyAllApproved, 0),
+			pr(domain.ReviewApproved, domain.ReviewApproved), nil},
+		{"all approved waits for pending", policy(domain.MergePolicyAllApproved, 0),
+			pr(domain.ReviewApproved, domain.ReviewPending), []string{"reviewer b has not approved"}},
+		{"all approved blocks changes requested", policy(domain.MergePolicyAllApproved, 0),
+			pr(domain.ReviewApproved, domain.ReviewChangesRequested), []string{"reviewer b requested changes"}},
+		{"all approved skips dismissed", policy(domain.MergePolicyAllApproved, 0),
+			pr(domain.ReviewApproved, domain.ReviewDismissed), nil},
+		{"all approved needs one approval", policy(domain.MergePolicyAllApproved, 0),
+			pr(domain.ReviewDismissed), []string{"no approvals from assigned reviewers"}},
+		{"all approved without reviewers", policy(domain.MergePolicyAllApproved, 0),
+			pr(), []string{"no approvals from assigned reviewers"}},
+		{"min approvals met", policy(domain.MergePolicyMinApprovals, 2),
+			pr(domain.ReviewApproved, domain.ReviewApproved, domain.ReviewPending), nil},
+		{"min approvals missing", policy(domain.MergePolicyMinApprovals, 2),
+			pr(domain.ReviewApproved, domain.ReviewPending), []string{"1 of 2 required approvals"}},
+		{"min approvals blocks changes requested", policy(domain.MergePolicyMinApprovals, 1),
+			pr(domain.ReviewApproved, domain.ReviewChangesRequested), []string{"reviewer b requested changes"}},
+		{"min approvals does not count dismissed", policy(domain.MergePolicyMinApprovals, 1),
+			pr(domain.ReviewDismissed), []string{"0 of 1 required approvals"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unmetMergeConditions(tt.settings, tt.pr); !slices.Equal(got, tt.want) {
+				t.Errorf("unmetMergeConditions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// Размер страницы списков PR.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// validateListFilter проверяет общие условия выборки списков PR.
+func validateListFilter(statuses []domain.PRStatus, sort domain.PRSort, limit int, from, to *time.Time) error {
+	for _, status := range statuses {
+		if !status.Valid() {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidListFilter, status)
+		}
+	}
+	if !sort.Valid() {
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidListFilter, sort)
+	}
+	if limit < 1 || limit > MaxPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListFilter, MaxPageSize)
+	}
+	if from != nil && to != nil && !to.After(*from) {
+		return fmt.Errorf("%w: created_to must be after created_from", ErrInvalidListFilter)
+	}
+	return nil
+}
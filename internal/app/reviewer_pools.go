@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// SetReviewerPools заменяет пулы ревьюеров команды и возвращает команду с новыми пулами.
+// Возвращает ErrNotFound, если команды нет, и ErrInvalidReviewerPool, если пул задан некорректно
+// или ссылается на несуществующую команду или пользователя.
+func (s *Service) SetReviewerPools(ctx context.Context, teamName string, pools []domain.ReviewerPool) (domain.Team, error) {
+	if err := s.requireTeamLead(ctx, teamName); err != nil {
+		return domain.Team{}, err
+	}
+	if err := validateReviewerPools(teamName, pools); err != nil {
+		return domain.Team{}, err
+	}
+	if err := s.repo.SetReviewerPools(ctx, teamName, pools); err != nil {
+		return domain.Team{}, err
+	}
+	return s.repo.GetTeamByName(ctx, teamName)
+}
+
+// validateReviewerPools проверяет режим пулов и то, что каждый пул задает либо другую команду, либо список пользователей.
+func validateReviewerPools(teamName string, pools []domain.ReviewerPool) error {
+	for i, pool := range pools {
+		if pool.Mode != domain.PoolModeAlways && pool.Mode != domain.PoolModeFallback {
+			return fmt.Errorf("%w: pool %d has unknown mode %q", ErrInvalidReviewerPool, i, pool.Mode)
+		}
+		if (pool.TeamName == "") == (len(pool.UserIDs) == 0) {
+			return fmt.Errorf("%w: pool %d must set either team_name or user_ids", ErrInvalidReviewerPool, i)
+		}
+		if pool.TeamName == teamName {
+			return fmt.Errorf("%w: pool %d refers to the team itself", ErrInvalidReviewerPool, i)
+		}
+	}
+	return nil
+}
+
+// selectReviewers выбирает до n ревьюеров для PR команды, кроме автора, уже назначенных
+// и отсутствующих сейчас пользователей. Кандидаты - участники команды и пулов always;
+// пулы fallback добирают ревьюеров, только если этих кандидатов не хватило.
+func (s *Service) selectReviewers(ctx context.Context, team domain.Team, authorID string, assigned []string, n int) ([]string, error) {
+	primary := append([]domain.User{}, team.Members...)
+	var fallback []domain.User
+	for _, pool := range team.ReviewerPools {
+		users, err := s.poolUsers(ctx, pool)
+		if err != nil {
+			return nil, err
+		}
+		if pool.Mode == domain.PoolModeAlways {
+			primary = append(primary, users...)
+		} else {
+			fallback = append(fallback, users...)
+		}
+	}
+
+	selector := s.selectorFor(team)
+	candidates, err := s.availableCandidates(ctx, reviewerCandidates(primary, authorID, assigned))
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selector.Select(ctx, team, candidates, n)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) >= n || len(fallback) == 0 {
+		return selected, nil
+	}
+
+	excluded := append(append([]string{}, assigned...), selected...)
+	candidates, err = s.availableCandidates(ctx, reviewerCandidates(fallback, authorID, excluded))
+	if err != nil {
+		return nil, err
+	}
+	more, err := selector.Select(ctx, team, candidates, n-len(selected))
+	if err != nil {
+		return nil, err
+	}
+	return append(selected, more...), nil
+}
+
+// poolUsers возвращает пользователей пула. Удаленные команды и пользователи пропускаются.
+func (s *Service) poolUsers(ctx context.Context, pool domain.ReviewerPool) ([]domain.User, error) {
+	if pool.TeamName != "" {
+		team, err := s.repo.GetTeamByName(ctx, pool.TeamName)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return team.Members, err
+	}
+
+	users := make([]domain.User, 0, len(pool.UserIDs))
+	for _, userID := range pool.UserIDs {
+		user, err := s.repo.GetUserByID(ctx, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, nil
+}
@@ -0,0 +1,90 @@
+package app_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+func TestReviewerPools(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []domain.User
+		pools   []domain.ReviewerPool
+		want    []string
+	}{
+		{
+			name:    "always pool joins team candidates",
+			members: []domain.User{member("author", 1)},
+			pools:   []domain.ReviewerPool{{Mode: domain.PoolModeAlways, TeamName: "platform"}},
+			want:    []string{"p1", "p2"},
+		},
+		{
+			name:    "fallback pool is unused when the team is enough",
+			members: []domain.User{member("author", 1), member("b", 1), member("c", 1)},
+			pools:   []domain.ReviewerPool{{Mode: domain.PoolModeFallback, TeamName: "platform"}},
+			want:    []string{"b", "c"},
+		},
+		{
+			name:    "fallback pool fills missing seats",
+			members: []domain.User{member("author", 1), member("b", 1)},
+			pools:   []domain.ReviewerPool{{Mode: domain.PoolModeFallback, UserIDs: []string{"p2"}}},
+			want:    []string{"b", "p2"},
+		},
+		{
+			name:    "pool skips inactive users",
+			members: []domain.User{member("author", 1)},
+			pools:   []domain.ReviewerPool{{Mode: domain.PoolModeAlways, UserIDs: []string{"p1", "idle"}}},
+			want:    []string{"p1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idle := member("idle", 1)
+			idle.IsActive = false
+			svc := newService(t, team("platform", domain.StrategyRandom, 2, member("p1", 1), member("p2", 1), idle))
+
+			backend := team("backend", domain.StrategyRandom, 2, tt.members...)
+			backend.ReviewerPools = tt.pools
+			if _, err := svc.CreateTeam(adminCtx, backend); err != nil {
+				t.Fatalf("create team: %v", err)
+			}
+			pr, err := svc.CreatePullRequest(adminCtx, "pr", "feature", "author", "", nil, false)
+			if err != nil {
+				t.Fatalf("create pr: %v", err)
+			}
+			if got := slices.Sorted(slices.Values(pr.AssignedReviewers)); !slices.Equal(got, tt.want) {
+				t.Errorf("reviewers %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetReviewerPoolsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		pool domain.ReviewerPool
+	}{
+		{"unknown mode", domain.ReviewerPool{Mode: "sometimes", TeamName: "platform"}},
+		{"neither team nor users", domain.ReviewerPool{Mode: domain.PoolModeAlways}},
+		{"both team and users", domain.ReviewerPool{Mode: domain.PoolModeAlways, TeamName: "platform", UserIDs: []string{"p1"}}},
+		{"team refers to itself", domain.ReviewerPool{Mode: domain.PoolModeFallback, TeamName: "backend"}},
+		{"missing team", domain.ReviewerPool{Mode: domain.PoolModeFallback, TeamName: "missing"}},
+		{"missing user", domain.ReviewerPool{Mode: domain.PoolModeFallback, UserIDs: []string{"missing"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t,
+				team("platform", domain.StrategyRandom, 2, member("p1", 1)),
+				team("backend", domain.StrategyRandom, 2, member("author", 1)),
+			)
+			_, err := svc.SetReviewerPools(adminCtx, "backend", []domain.ReviewerPool{tt.pool})
+			if !errors.Is(err, app.ErrInvalidReviewerPool) {
+				t.Errorf("got %v, want ErrInvalidReviewerPool", err)
+			}
+		})
+	}
+}
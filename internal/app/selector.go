@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// ReviewerSelector выбирает до n ревьюеров из списка кандидатов команды.
+// Кандидаты уже отфильтрованы сервисом: активны и не являются автором PR.
+type ReviewerSelector interface {
+	Select(ctx context.Context, team domain.Team, candidates []domain.User, n int) ([]string, error)
+}
+
+// loadFunc возвращает количество открытых ревью для каждого из пользователей.
+type loadFunc func(ctx context.Context, userIDs []string) (map[string]int, error)
+
+// randomSelector выбирает ревьюеров равновероятно.
+type randomSelector struct{}
+
+func (randomSelector) Select(_ context.Context, _ domain.Team, candidates []domain.User, n int) ([]string, error) {
+	return firstIDs(shuffledCopy(candidates), n), nil
+}
+
+// roundRobinSelector выбирает ревьюеров по кругу отдельно для каждой команды.
+// Позиция хранится в памяти процесса и сбрасывается при перезапуске.
+type roundRobinSelector struct {
+	mu   sync.Mutex
+	next map[string]int
+}
+
+func newRoundRobinSelector() *roundRobinSelector {
+	return &roundRobinSelector{next: make(map[string]int)}
+}
+
+func (s *roundRobinSelector) Select(_ context.Context, team domain.Team, candidates []domain.User, n int) ([]string, error) {
+	if len(candidates) == 0 || n <= 0 {
+		return []string{}, nil
+	}
+
+	sorted := sortedByID(candidates)
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+
+	s.mu.Lock()
+	start := s.next[team.Name] % len(sorted)
+	s.next[team.Name] = start + n
+	s.mu.Unlock()
+
+	reviewers := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		reviewers = append(reviewers, sorted[(start+i)%len(sorted)].ID)
+	}
+	return reviewers, nil
+}
+
+// leastLoadedSelector выбирает ревьюеров с наименьшим числом открытых ревью.
+// При равной загрузке порядок между кандидатами определяется случайно.
+type leastLoadedSelector struct {
+	loads loadFunc
+}
+
+func (s leastLoadedSelector) Select(ctx context.Context, _ domain.Team, candidates []domain.User, n int) ([]string, error) {
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.ID
+	}
+	loads, err := s.loads(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	shuffled := shuffledCopy(candidates)
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return loads[shuffled[i].ID] < loads[shuffled[j].ID]
+	})
+	return firstIDs(shuffled, n), nil
+}
+
+// weightedSelector выбирает ревьюеров случайно пропорционально их весу.
+// Пользователи с нулевым весом исключены из выбора: если таких все, ревьюеры не выбираются.
+type weightedSelector struct{}
+
+func (weightedSelector) Select(_ context.Context, _ domain.Team, candidates []domain.User, n int) ([]string, error) {
+	pool := make([]domain.User, 0, len(candidates))
+	total := 0
+	for _, c := range candidates {
+		if c.ReviewWeight > 0 {
+			pool = append(pool, c)
+			total += c.ReviewWeight
+		}
+	}
+
+	reviewers := make([]string, 0, n)
+	for len(reviewers) < n && len(pool) > 0 {
+		point := rand.Intn(total)
+		for i, c := range pool {
+			if point < c.ReviewWeight {
+				reviewers = append(reviewers, c.ID)
+				total -= c.ReviewWeight
+				pool = append(pool[:i], pool[i+1:]...)
+				break
+			}
+			point -= c.ReviewWeight
+		}
+	}
+	return reviewers, nil
+}
+
+func shuffledCopy(users []domain.User) []domain.User {
+	shuffled := make([]domain.User, len(users))
+	copy(shuffled, users)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+func sortedByID(users []domain.User) []domain.User {
+	sorted := make([]domain.User, len(users))
+	copy(sorted, users)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+func firstIDs(users []domain.User, n int) []string {
+	ids := make([]string, 0, n)
+	for i := 0; i < len(users) && i < n; i++ {
+		ids = append(ids, users[i].ID)
+	}
+	return ids
+}
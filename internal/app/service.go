@@ -3,26 +3,67 @@ package app
 import (
 	"context"
 	"errors"
-	"math/rand"
+	"fmt"
 	"time"
 
 	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/metrics"
 	"github.com/wsppppp/manage-pull-request/internal/repository"
 )
 
 // Service инкапсулирует бизнес-логику приложения.
 type Service struct {
-	repo repository.Repository
+	repo      repository.Repository
+	selectors map[domain.ReviewerStrategy]ReviewerSelector
 }
 
 func New(repo repository.Repository) *Service {
-	return &Service{
+	s := &Service{
 		repo: repo,
 	}
+	s.selectors = map[domain.ReviewerStrategy]ReviewerSelector{
+		domain.StrategyRandom:      randomSelector{},
+		domain.StrategyRoundRobin:  newRoundRobinSelector(),
+		domain.StrategyLeastLoaded: leastLoadedSelector{loads: repo.CountOpenReviews},
+		domain.StrategyWeighted:    weightedSelector{},
+	}
+	return s
+}
+
+// TeamSettingsUpdate описывает частичное изменение настроек команды. Поля со значением nil не меняются.
+type TeamSettingsUpdate struct {
+	ReviewerStrategy  *domain.ReviewerStrategy
+	MinReviewers      *int
+	MaxReviewers      *int
+	MergePolicy       *domain.MergePolicy
+	RequiredApprovals *int
 }
 
-// CreateTeam создает команду. Возвращает ошибку ErrTeamExists, если команда уже существует.
+// CreateTeam создает команду. Возвращает ошибку ErrTeamExists, если команда уже существует,
+// ErrUnknownStrategy или ErrInvalidTeamSettings, если настройки команды некорректны,
+// ErrInvalidReviewWeight, если у участника отрицательный вес, ErrInvalidReviewerPool, если некорректны пулы ревьюеров,
+// и ErrInvalidCodeOwner, если некорректны правила владения кодом.
 func (s *Service) CreateTeam(ctx context.Context, team domain.Team) (domain.Team, error) {
+	if err := s.requireAdmin(ctx, "only admins can create teams"); err != nil {
+		return domain.Team{}, err
+	}
+	if team.ReviewerStrategy == "" {
+		team.ReviewerStrategy = domain.StrategyRandom
+	}
+	if err := s.validateTeamSettings(team.TeamSettings); err != nil {
+		return domain.Team{}, err
+	}
+	for _, member := range team.Members {
+		if err := validateReviewWeight(member); err != nil {
+			return domain.Team{}, err
+		}
+	}
+	if err := validateReviewerPools(team.Name, team.ReviewerPools); err != nil {
+		return domain.Team{}, err
+	}
+	if err := validateCodeOwners(team.CodeOwners); err != nil {
+		return domain.Team{}, err
+	}
 	return s.repo.CreateTeam(ctx, team)
 }
 
@@ -31,81 +72,169 @@ func (s *Service) GetTeam(ctx context.Context, teamName string) (domain.Team, er
 	return s.repo.GetTeamByName(ctx, teamName)
 }
 
-// SetUserActivity обновляет статус активности пользователя.
-func (s *Service) SetUserActivity(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
-	return s.repo.SetUserActivity(ctx, userID, isActive)
+// UpdateTeamSettings меняет настройки назначения ревьюеров. Возвращает ErrNotFound, если команда не найдена.
+func (s *Service) UpdateTeamSettings(ctx context.Context, teamName string, update TeamSettingsUpdate) (domain.TeamSettings, error) {
+	if err := s.requireTeamLead(ctx, teamName); err != nil {
+		return domain.TeamSettings{}, err
+	}
+	team, err := s.repo.GetTeamByName(ctx, teamName)
+	if err != nil {
+		return domain.TeamSettings{}, err
+	}
+
+	settings := team.TeamSettings
+	if update.ReviewerStrategy != nil {
+		settings.ReviewerStrategy = *update.ReviewerStrategy
+	}
+	if update.MinReviewers != nil {
+		settings.MinReviewers = *update.MinReviewers
+	}
+	if update.MaxReviewers != nil {
+		settings.MaxReviewers = *update.MaxReviewers
+	}
+	if update.MergePolicy != nil {
+		settings.MergePolicy = *update.MergePolicy
+	}
+	if update.RequiredApprovals != nil {
+		settings.RequiredApprovals = *update.RequiredApprovals
+	}
+	if err := s.validateTeamSettings(settings); err != nil {
+		return domain.TeamSettings{}, err
+	}
+
+	return s.repo.UpdateTeamSettings(ctx, teamName, settings)
 }
 
-func (s *Service) CreatePullRequest(ctx context.Context, prID, prName, authorID string) (*domain.PullRequest, error) {
-	author, err := s.repo.GetUserByID(ctx, authorID)
-	if err != nil {
-		if errors.Is(err, ErrUserNotFound) {
-			return nil, ErrAuthorNotFound
-		}
+// SetUserActivity обновляет статус активности пользователя. Чужую активность меняют только администраторы.
+func (s *Service) SetUserActivity(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
+	if err := s.requireSelf(ctx, userID, "only admins can change other users' activity"); err != nil {
 		return nil, err
 	}
+	return s.repo.SetUserActivity(ctx, userID, isActive)
+}
 
-	if author.TeamName == "" {
-		return nil, ErrAuthorNotFound
+// CreatePullRequest создает PR и назначает ревьюеров из команды teamName, в которой должен состоять автор.
+// Пустой teamName означает основную команду автора. Первыми назначаются владельцы файлов changedFiles
+// по правилам владения кодом команды. Черновик (draft) создается без ревьюеров.
+// Возвращает ErrNotTeamMember, если автор не состоит в указанной команде.
+func (s *Service) CreatePullRequest(ctx context.Context, prID, prName, authorID, teamName string, changedFiles []string, draft bool) (*domain.PullRequest, error) {
+	if err := s.requireAuthor(ctx, authorID); err != nil {
+		return nil, err
 	}
-
-	team, err := s.repo.GetTeamByName(ctx, author.TeamName)
+	team, err := s.authorTeam(ctx, authorID, teamName)
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			return nil, ErrAuthorNotFound
-		}
 		return nil, err
 	}
 
-	candidates := make([]domain.User, 0)
-	for _, member := range team.Members {
-		if member.IsActive && member.ID != authorID {
-			candidates = append(candidates, member)
+	changedFiles = normalizeChangedFiles(changedFiles)
+	status := domain.StatusOpen
+	var owners, others []string
+	if draft {
+		status = domain.StatusDraft
+	} else {
+		owners, others, err = s.assignReviewers(ctx, team, authorID, changedFiles, team.MaxReviewers)
+		if err != nil {
+			return nil, err
 		}
 	}
+	reviewers := append(append([]string{}, owners...), others...)
 
-	rand.Shuffle(len(candidates), func(i, j int) {
-		candidates[i], candidates[j] = candidates[j], candidates[i]
-	})
-
-	reviewers := make([]string, 0, 2)
-	for i := 0; i < len(candidates) && i < 2; i++ {
-		reviewers = append(reviewers, candidates[i].ID)
-	}
 	pr := domain.PullRequest{
 		ID:                prID,
 		Name:              prName,
 		AuthorID:          authorID,
-		Status:            domain.StatusOpen,
+		TeamName:          team.Name,
+		Status:            status,
+		ChangedFiles:      changedFiles,
 		AssignedReviewers: reviewers,
+		RequiredReviewers: team.MinReviewers,
 		CreatedAt:         time.Now(),
 	}
-	return s.repo.CreatePullRequest(ctx, pr)
+
+	created := newEvent(ctx, prID, domain.EventCreated)
+	created.Details = string(status)
+	events := append([]domain.PREvent{created}, ownerEvents(ctx, prID, owners, others)...)
+	createdPR, err := s.repo.CreatePullRequest(ctx, pr, events...)
+	if err != nil {
+		return nil, err
+	}
+	metrics.PullRequestsCreated.Inc()
+	return createdPR, nil
 }
 
-// MergePullRequest мерджит pr.
-func (s *Service) MergePullRequest(ctx context.Context, prID string) (*domain.PullRequest, error) {
+// MergePullRequest мерджит pr, если выполнена политика слияния команды автора. Слить PR может его автор,
+// руководитель команды PR и администратор. При force, доступном только администраторам, политика
+// не проверяется, а PR помечается как принудительно слитый.
+// Операция идемпотентна: для уже слитого PR возвращается его текущее состояние.
+func (s *Service) MergePullRequest(ctx context.Context, prID string, force bool, ifMatch int) (*domain.PullRequest, error) {
+	if force {
+		if err := s.requireAdmin(ctx, "force merge requires an admin token"); err != nil {
+			return nil, err
+		}
+	}
 	pr, err := s.repo.GetPullRequestByID(ctx, prID)
 	if err != nil {
 		return nil, err // Пробрасываем ошибку (например, ErrPRNotFound)
 	}
+	if err := s.requirePRAccess(ctx, pr, false, "only the author, the team lead and admins can merge the pull request"); err != nil {
+		return nil, err
+	}
 
 	if pr.Status == domain.StatusMerged {
-		return nil, ErrPRMerged
+		return pr, nil
+	}
+	if err := checkVersion(pr, ifMatch); err != nil {
+		return nil, err
+	}
+	if !pr.Status.CanTransitionTo(domain.StatusMerged) {
+		return nil, &ErrInvalidTransition{From: pr.Status, To: domain.StatusMerged}
 	}
 
-	return s.repo.MergePullRequest(ctx, prID)
+	if !force {
+		team, err := s.prTeam(ctx, pr)
+		if err != nil {
+			return nil, err
+		}
+		if unmet := unmetMergeConditions(team.TeamSettings, pr); len(unmet) > 0 {
+			return nil, &ErrMergeBlocked{Reasons: unmet}
+		}
+	}
+
+	merged := newEvent(ctx, prID, domain.EventMerged)
+	if force {
+		merged.Details = "forced"
+	}
+	mergedPR, mergedNow, err := s.repo.MergePullRequest(ctx, prID, pr.Version, force, merged)
+	if err != nil {
+		return nil, err
+	}
+	// Параллельный запрос мог слить PR раньше: тогда слияние уже учтено в метрике.
+	if mergedNow {
+		metrics.PullRequestsMerged.Inc()
+	}
+	return mergedPR, nil
 }
 
-// ReassignReviewer заменяет ревьюера на нового
-func (s *Service) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (*domain.PullRequest, string, error) {
+// ReassignReviewer заменяет одного ревьюера на нового; остальные ревьюеры не меняются. Кроме тех, кто может менять PR, заменить себя может сам ревьюер.
+func (s *Service) ReassignReviewer(ctx context.Context, prID, oldReviewerID string, ifMatch int) (*domain.PullRequest, string, error) {
 	pr, err := s.repo.GetPullRequestByID(ctx, prID)
 	if err != nil {
 		return nil, "", err
 	}
+	if p, _ := PrincipalFromContext(ctx); p.UserID == "" || p.UserID != oldReviewerID {
+		if err := s.requirePRAccess(ctx, pr, true, "only the reviewer, the author, the team lead, bots and admins can reassign the reviewer"); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := checkVersion(pr, ifMatch); err != nil {
+		return nil, "", err
+	}
 	if pr.Status == domain.StatusMerged {
 		return nil, "", ErrPRMerged
 	}
+	if pr.Status != domain.StatusOpen {
+		return nil, "", ErrPRNotOpen
+	}
 
 	isAssigned := false
 	for _, reviewer := range pr.AssignedReviewers {
@@ -118,33 +247,21 @@ func (s *Service) ReassignReviewer(ctx context.Context, prID, oldReviewerID stri
 		return nil, "", ErrReviewerNotAssigned
 	}
 
-	author, err := s.repo.GetUserByID(ctx, pr.AuthorID)
-	if err != nil {
-		return nil, "", ErrAuthorNotFound
-	}
-	team, err := s.repo.GetTeamByName(ctx, author.TeamName)
+	team, err := s.prTeam(ctx, pr)
 	if err != nil {
-		return nil, "", ErrAuthorNotFound
+		return nil, "", err
 	}
 
-	currentReviewers := make(map[string]struct{})
-	for _, r := range pr.AssignedReviewers {
-		currentReviewers[r] = struct{}{}
-	}
-	candidates := make([]string, 0)
-	for _, member := range team.Members {
-		_, isReviewer := currentReviewers[member.ID]
-		if member.IsActive && member.ID != pr.AuthorID && !isReviewer {
-			candidates = append(candidates, member.ID)
-		}
+	// Заменяется ровно один ревьюер: недоукомплектованный PR остается таким же (under_reviewed).
+	selected, err := s.selectReviewers(ctx, team, pr.AuthorID, pr.AssignedReviewers, 1)
+	if err != nil {
+		return nil, "", err
 	}
-
-	if len(candidates) == 0 {
+	if len(selected) == 0 {
+		metrics.NoCandidate.WithLabelValues(metrics.ReasonManual).Inc()
 		return nil, "", ErrNoCandidates
 	}
-
-	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
-	newReviewerID := candidates[0]
+	newReviewerID := selected[0]
 
 	newReviewersList := make([]string, 0, len(pr.AssignedReviewers))
 	for _, reviewer := range pr.AssignedReviewers {
@@ -156,14 +273,236 @@ func (s *Service) ReassignReviewer(ctx context.Context, prID, oldReviewerID stri
 	}
 	pr.AssignedReviewers = newReviewersList
 
-	if err := s.repo.UpdatePullRequestReviewers(ctx, prID, pr.AssignedReviewers); err != nil {
+	// Ревью заменённого ревьюера отбрасывается, новый ревьюер начинает с PENDING.
+	delete(pr.ReviewStates, oldReviewerID)
+	pr.ReviewStates[newReviewerID] = domain.ReviewPending
+
+	replaced := newEvent(ctx, prID, domain.EventReviewerReplaced)
+	replaced.OldReviewerID = oldReviewerID
+	replaced.ReviewerID = newReviewerID
+
+	if err := s.repo.UpdatePullRequestReviewers(ctx, prID, pr.Version, pr.AssignedReviewers, replaced); err != nil {
 		return nil, "", err
 	}
+	pr.Version++
+	metrics.ReviewerReassignments.WithLabelValues(metrics.ReasonManual).Inc()
 
 	return pr, newReviewerID, nil
 }
 
-// GetPullRequestsByReviewer возвращает все открытые pr для ревьюера.
-func (s *Service) GetPullRequestsByReviewer(ctx context.Context, userID string) ([]*domain.PullRequest, error) {
-	return s.repo.GetOpenPullRequestsByReviewer(ctx, userID)
+// GetPullRequest возвращает PR по ID. Возвращает ErrPRNotFound, если PR не найден.
+func (s *Service) GetPullRequest(ctx context.Context, prID string) (*domain.PullRequest, error) {
+	return s.repo.GetPullRequestByID(ctx, prID)
+}
+
+// SubmitReview фиксирует решение ревьюера по открытому PR. Решение отправляет сам ревьюер или администратор;
+// отклонить ревью (DISMISSED) могут также автор PR и руководитель команды PR.
+func (s *Service) SubmitReview(ctx context.Context, prID, reviewerID string, state domain.ReviewState, ifMatch int) (*domain.PullRequest, error) {
+	if state != domain.ReviewDismissed {
+		if err := s.requireSelf(ctx, reviewerID, "reviews can only be submitted by the reviewer"); err != nil {
+			return nil, err
+		}
+	}
+	if !state.Valid() {
+		return nil, ErrInvalidReviewState
+	}
+
+	pr, err := s.repo.GetPullRequestByID(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if state == domain.ReviewDismissed {
+		if p, _ := PrincipalFromContext(ctx); p.UserID == "" || p.UserID != reviewerID {
+			if err := s.requirePRAccess(ctx, pr, false, "only the reviewer, the author, the team lead and admins can dismiss the review"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := checkVersion(pr, ifMatch); err != nil {
+		return nil, err
+	}
+	if pr.Status == domain.StatusMerged {
+		return nil, ErrPRMerged
+	}
+	if pr.Status != domain.StatusOpen {
+		return nil, ErrPRNotOpen
+	}
+
+	current, ok := pr.ReviewStates[reviewerID]
+	if !ok {
+		return nil, ErrReviewerNotAssigned
+	}
+	if !current.CanTransitionTo(state) {
+		return nil, &ErrInvalidReviewTransition{From: current, To: state}
+	}
+
+	submitted := newEvent(ctx, prID, domain.EventReviewSubmitted)
+	submitted.ReviewerID = reviewerID
+	submitted.Details = string(state)
+	if err := s.repo.SetReviewState(ctx, prID, pr.Version, reviewerID, state, submitted); err != nil {
+		return nil, err
+	}
+	pr.ReviewStates[reviewerID] = state
+	pr.Version++
+	return pr, nil
+}
+
+// ListReviewerPullRequests возвращает страницу PR, на которые назначен ревьюер. По умолчанию выбираются
+// открытые PR, сначала новые, по DefaultPageSize на странице.
+// Возвращает ErrInvalidListFilter, если условия выборки некорректны.
+func (s *Service) ListReviewerPullRequests(ctx context.Context, filter domain.ReviewerPRFilter) (domain.PRPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = domain.SortCreatedDesc
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if err := validateListFilter(filter.Statuses, filter.Sort, filter.Limit, filter.CreatedFrom, filter.CreatedTo); err != nil {
+		return domain.PRPage{}, err
+	}
+	return s.repo.ListReviewerPullRequests(ctx, filter)
+}
+
+// ListPullRequests возвращает страницу PR по условиям filter. По умолчанию выдаются PR всех статусов,
+// сначала новые, по DefaultPageSize на странице.
+// Возвращает ErrInvalidListFilter, если условия выборки некорректны.
+func (s *Service) ListPullRequests(ctx context.Context, filter domain.PRFilter) (domain.PRPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = domain.SortCreatedDesc
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if err := validateListFilter(filter.Statuses, filter.Sort, filter.Limit, filter.CreatedFrom, filter.CreatedTo); err != nil {
+		return domain.PRPage{}, err
+	}
+	if filter.MergedFrom != nil && filter.MergedTo != nil && !filter.MergedTo.After(*filter.MergedFrom) {
+		return domain.PRPage{}, fmt.Errorf("%w: merged_to must be after merged_from", ErrInvalidListFilter)
+	}
+	return s.repo.ListPullRequests(ctx, filter)
+}
+
+// openReviews возвращает все открытые PR ревьюера, при pendingOnly - только те, по которым он ещё не принял решение.
+func (s *Service) openReviews(ctx context.Context, userID string, pendingOnly bool) ([]*domain.PullRequest, error) {
+	page, err := s.repo.ListReviewerPullRequests(ctx, domain.ReviewerPRFilter{
+		ReviewerID: userID, PendingOnly: pendingOnly, Sort: domain.SortCreatedAsc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page.PullRequests, nil
+}
+
+// GetPullRequestHistory возвращает журнал событий PR в порядке их записи.
+// Возвращает ErrPRNotFound, если PR не найден.
+func (s *Service) GetPullRequestHistory(ctx context.Context, prID string) ([]domain.PREvent, error) {
+	if _, err := s.repo.GetPullRequestByID(ctx, prID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPullRequestEvents(ctx, prID)
+}
+
+// selectorFor возвращает стратегию выбора ревьюеров, настроенную для команды.
+func (s *Service) selectorFor(team domain.Team) ReviewerSelector {
+	if selector, ok := s.selectors[team.ReviewerStrategy]; ok {
+		return selector
+	}
+	return s.selectors[domain.StrategyRandom]
+}
+
+// checkVersion проверяет версию PR из If-Match. Нулевая версия означает отсутствие условия.
+func checkVersion(pr *domain.PullRequest, ifMatch int) error {
+	if ifMatch != 0 && pr.Version != ifMatch {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// reviewerCandidates возвращает активных пользователей без повторов, кроме автора и уже назначенных ревьюеров.
+func reviewerCandidates(users []domain.User, authorID string, assigned []string) []domain.User {
+	excluded := make(map[string]struct{}, len(assigned)+1)
+	excluded[authorID] = struct{}{}
+	for _, id := range assigned {
+		excluded[id] = struct{}{}
+	}
+
+	candidates := make([]domain.User, 0)
+	for _, user := range users {
+		if _, skip := excluded[user.ID]; user.IsActive && !skip {
+			candidates = append(candidates, user)
+			excluded[user.ID] = struct{}{}
+		}
+	}
+	return candidates
+}
+
+// authorTeam возвращает команду teamName, из которой автор PR получает ревьюеров, а при пустом teamName -
+// основную команду автора. Возвращает ErrAuthorNotFound, если автора или команды нет,
+// и ErrNotTeamMember, если автор не состоит в указанной команде.
+func (s *Service) authorTeam(ctx context.Context, authorID, teamName string) (domain.Team, error) {
+	author, err := s.repo.GetUserByID(ctx, authorID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return domain.Team{}, ErrAuthorNotFound
+		}
+		return domain.Team{}, err
+	}
+	if teamName == "" {
+		teamName = author.TeamName
+	} else if !author.InTeam(teamName) {
+		return domain.Team{}, ErrNotTeamMember
+	}
+	if teamName == "" {
+		return domain.Team{}, ErrAuthorNotFound
+	}
+	return s.teamOrAuthorNotFound(ctx, teamName)
+}
+
+// prTeam возвращает команду, от имени которой создан PR. Для PR без команды
+// (например, если её удалили) используется основная команда автора.
+func (s *Service) prTeam(ctx context.Context, pr *domain.PullRequest) (domain.Team, error) {
+	if pr.TeamName == "" {
+		return s.authorTeam(ctx, pr.AuthorID, "")
+	}
+	return s.teamOrAuthorNotFound(ctx, pr.TeamName)
+}
+
+// teamOrAuthorNotFound получает команду, заменяя ErrNotFound на ErrAuthorNotFound.
+func (s *Service) teamOrAuthorNotFound(ctx context.Context, teamName string) (domain.Team, error) {
+	team, err := s.repo.GetTeamByName(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return domain.Team{}, ErrAuthorNotFound
+		}
+		return domain.Team{}, err
+	}
+	return team, nil
+}
+
+// validateReviewWeight проверяет, что вес участника для стратегии weighted не отрицательный.
+func validateReviewWeight(member domain.User) error {
+	if member.ReviewWeight < 0 {
+		return fmt.Errorf("%w: got %d for user %s", ErrInvalidReviewWeight, member.ReviewWeight, member.ID)
+	}
+	return nil
+}
+
+// validateTeamSettings проверяет стратегию, границы числа ревьюеров и политику слияния.
+func (s *Service) validateTeamSettings(settings domain.TeamSettings) error {
+	if _, ok := s.selectors[settings.ReviewerStrategy]; !ok {
+		return ErrUnknownStrategy
+	}
+	if settings.MinReviewers < 0 || settings.MaxReviewers < settings.MinReviewers {
+		return fmt.Errorf("%w: expected 0 <= min_reviewers <= max_reviewers", ErrInvalidTeamSettings)
+	}
+	if !validMergePolicy(settings.MergePolicy) {
+		return fmt.Errorf("%w: unknown merge_policy %q", ErrInvalidTeamSettings, settings.MergePolicy)
+	}
+	if settings.MergePolicy == domain.MergePolicyMinApprovals && settings.RequiredApprovals < 1 {
+		return fmt.Errorf("%w: min_approvals policy requires required_approvals >= 1", ErrInvalidTeamSettings)
+	}
+	if settings.RequiredApprovals < 0 {
+		return fmt.Errorf("%w: required_approvals must not be negative", ErrInvalidTeamSettings)
+	}
+	return nil
 }
@@ -0,0 +1,297 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/repository/memory"
+)
+
+// adminCtx - контекст запроса с токеном администратора.
+var adminCtx = as(domain.RoleAdmin, "")
+
+// as возвращает контекст запроса с токеном роли role от имени пользователя userID.
+func as(role domain.Role, userID string) context.Context {
+	return app.WithPrincipal(context.Background(), domain.APIToken{ID: 1, Name: string(role), Role: role, UserID: userID})
+}
+
+// member возвращает активного пользователя с весом weight.
+func member(id string, weight int) domain.User {
+	return domain.User{ID: id, Username: id, IsActive: true, ReviewWeight: weight}
+}
+
+// team возвращает команду со стратегией strategy, которая назначает до maxReviewers ревьюеров.
+func team(name string, strategy domain.ReviewerStrategy, maxReviewers int, members ...domain.User) domain.Team {
+	settings := domain.DefaultTeamSettings()
+	settings.ReviewerStrategy = strategy
+	settings.MinReviewers = min(settings.MinReviewers, maxReviewers)
+	settings.MaxReviewers = maxReviewers
+	return domain.Team{Name: name, TeamSettings: settings, Members: members}
+}
+
+// newService создает сервис на хранилище в памяти с командами teams.
+func newService(t *testing.T, teams ...domain.Team) *app.Service {
+	t.Helper()
+	svc := app.New(memory.New())
+	for _, team := range teams {
+		if _, err := svc.CreateTeam(adminCtx, team); err != nil {
+			t.Fatalf("create team %s: %v", team.Name, err)
+		}
+	}
+	return svc
+}
+
+// createPRs создает n открытых PR автора с ID вида <prefix>-<номер> и возвращает их.
+func createPRs(t *testing.T, svc *app.Service, prefix, authorID string, n int) []*domain.PullRequest {
+	t.Helper()
+	prs := make([]*domain.PullRequest, 0, n)
+	for i := range n {
+		pr, err := svc.CreatePullRequest(adminCtx, fmt.Sprintf("%s-%d", prefix, i), "feature", authorID, "", nil, false)
+		if err != nil {
+			t.Fatalf("create pr: %v", err)
+		}
+		prs = append(prs, pr)
+	}
+	return prs
+}
+
+func TestReviewerStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		team    domain.Team
+		prs     int
+		want    [][]string // ревьюеры каждого PR по порядку ID; nil - проверяется только состав кандидатов
+		allowed []string
+	}{
+		{
+			name:    "random picks distinct candidates",
+			team:    team("random", domain.StrategyRandom, 2, member("author", 1), member("b", 1), member("c", 1), member("d", 1)),
+			prs:     20,
+			allowed: []string{"b", "c", "d"},
+		},
+		{
+			name: "round robin rotates by user ID",
+			team: team("round-robin", domain.StrategyRoundRobin, 1, member("author", 1), member("d", 1), member("b", 1), member("c", 1)),
+			prs:  4,
+			want: [][]string{{"b"}, {"c"}, {"d"}, {"b"}},
+		},
+		{
+			name: "round robin fills every seat",
+			team: team("round-robin-pairs", domain.StrategyRoundRobin, 2, member("author", 1), member("b", 1), member("c", 1), member("d", 1)),
+			prs:  2,
+			want: [][]string{{"b", "c"}, {"b", "d"}},
+		},
+		{
+			name:    "weighted never picks zero weight",
+			team:    team("weighted", domain.StrategyWeighted, 2, member("author", 1), member("b", 0), member("c", 3), member("d", 0)),
+			prs:     20,
+			allowed: []string{"c"},
+		},
+		{
+			name: "weighted with only zero weights assigns nobody",
+			team: team("weighted-zero", domain.StrategyWeighted, 2, member("author", 1), member("b", 0), member("c", 0)),
+			prs:  3,
+			want: [][]string{{}, {}, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t, tt.team)
+			prs := createPRs(t, svc, "pr", "author", tt.prs)
+			for i, pr := range prs {
+				if tt.want != nil && !slices.Equal(slices.Sorted(slices.Values(pr.AssignedReviewers)), tt.want[i]) {
+					t.Errorf("pr %d: reviewers %v, want %v", i, pr.AssignedReviewers, tt.want[i])
+				}
+				if tt.allowed == nil {
+					continue
+				}
+				for _, reviewer := range pr.AssignedReviewers {
+					if !slices.Contains(tt.allowed, reviewer) {
+						t.Errorf("pr %d: reviewer %s is not among %v", i, reviewer, tt.allowed)
+					}
+				}
+				if len(pr.AssignedReviewers) != min(tt.team.MaxReviewers, len(tt.allowed)) {
+					t.Errorf("pr %d: got %d reviewers %v", i, len(pr.AssignedReviewers), pr.AssignedReviewers)
+				}
+				if len(slices.Compact(slices.Sorted(slices.Values(pr.AssignedReviewers)))) != len(pr.AssignedReviewers) {
+					t.Errorf("pr %d: repeated reviewers %v", i, pr.AssignedReviewers)
+				}
+			}
+		})
+	}
+}
+
+func TestLeastLoadedStrategy(t *testing.T) {
+	svc := newService(t, team("least-loaded", domain.StrategyLeastLoaded, 1, member("author", 1), member("b", 1), member("c", 1)))
+	for round := range 3 {
+		prs := createPRs(t, svc, fmt.Sprintf("round-%d", round), "author", 2)
+		if got := []string{prs[0].AssignedReviewers[0], prs[1].AssignedReviewers[0]}; got[0] == got[1] {
+			t.Fatalf("round %d: both pull requests went to %s", round, got[0])
+		}
+		// Закрытые PR не нагружают ревьюеров.
+		for _, pr := range prs {
+			if _, err := svc.ClosePullRequest(adminCtx, pr.ID, 0); err != nil {
+				t.Fatalf("close pr: %v", err)
+			}
+		}
+	}
+}
+
+func TestPullRequestStatusTransitions(t *testing.T) {
+	type step func(svc *app.Service, prID string) (*domain.PullRequest, error)
+	var (
+		ready step = func(svc *app.Service, prID string) (*domain.PullRequest, error) {
+			return svc.MarkReady(adminCtx, prID, 0)
+		}
+		closePR step = func(svc *app.Service, prID string) (*domain.PullRequest, error) {
+			return svc.ClosePullRequest(adminCtx, prID, 0)
+		}
+		reopen step = func(svc *app.Service, prID string) (*domain.PullRequest, error) {
+			return svc.ReopenPullRequest(adminCtx, prID, 0)
+		}
+		merge step = func(svc *app.Service, prID string) (*domain.PullRequest, error) {
+			return svc.MergePullRequest(adminCtx, prID, false, 0)
+		}
+	)
+
+	tests := []struct {
+		name          string
+		draft         bool
+		steps         []step
+		wantStatus    domain.PRStatus
+		wantReviewers int
+		wantErr       bool // последний шаг - недопустимый переход
+	}{
+		{name: "draft has no reviewers", draft: true, wantStatus: domain.StatusDraft},
+		{name: "ready assigns reviewers", draft: true, steps: []step{ready}, wantStatus: domain.StatusOpen, wantReviewers: 2},
+		{name: "draft can be closed", draft: true, steps: []step{closePR}, wantStatus: domain.StatusClosed},
+		{name: "draft cannot be merged", draft: true, steps: []step{merge}, wantErr: true},
+		{name: "open cannot be marked ready", steps: []step{ready}, wantErr: true},
+		{name: "close removes reviewers", steps: []step{closePR}, wantStatus: domain.StatusClosed},
+		{name: "reopen assigns reviewers again", steps: []step{closePR, reopen}, wantStatus: domain.StatusOpen, wantReviewers: 2},
+		{name: "closed draft cannot be marked ready", draft: true, steps: []step{closePR, ready}, wantErr: true},
+		{name: "closed cannot be merged", steps: []step{closePR, merge}, wantErr: true},
+		{name: "open can be merged", steps: []step{merge}, wantStatus: domain.StatusMerged, wantReviewers: 2},
+		{name: "merged cannot be closed", steps: []step{merge, closePR}, wantErr: true},
+		{name: "merged cannot be reopened", steps: []step{merge, reopen}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t, team("backend", domain.StrategyRandom, 2, member("author", 1), member("b", 1), member("c", 1)))
+			pr, err := svc.CreatePullRequest(adminCtx, "pr", "feature", "author", "", nil, tt.draft)
+			if err != nil {
+				t.Fatalf("create pr: %v", err)
+			}
+			for i, step := range tt.steps {
+				pr, err = step(svc, pr.ID)
+				if err != nil && !(tt.wantErr && i == len(tt.steps)-1) {
+					t.Fatalf("step %d: %v", i, err)
+				}
+			}
+
+			if tt.wantErr {
+				var transition *app.ErrInvalidTransition
+				if !errors.As(err, &transition) {
+					t.Fatalf("got %v, want ErrInvalidTransition", err)
+				}
+				return
+			}
+			if pr.Status != tt.wantStatus || len(pr.AssignedReviewers) != tt.wantReviewers {
+				t.Errorf("got status %s with reviewers %v, want %s with %d reviewers",
+					pr.Status, pr.AssignedReviewers, tt.wantStatus, tt.wantReviewers)
+			}
+		})
+	}
+}
+
+func TestSubmitReview(t *testing.T) {
+	tests := []struct {
+		name    string
+		states  []domain.ReviewState
+		wantErr error
+	}{
+		{name: "approve", states: []domain.ReviewState{domain.ReviewApproved}},
+		{name: "request changes then approve", states: []domain.ReviewState{domain.ReviewChangesRequested, domain.ReviewApproved}},
+		{name: "repeat the same state", states: []domain.ReviewState{domain.ReviewApproved, domain.ReviewApproved}},
+		{name: "re-request dismissed review", states: []domain.ReviewState{domain.ReviewDismissed, domain.ReviewPending}},
+		{name: "unknown state", states: []domain.ReviewState{"LGTM"}, wantErr: app.ErrInvalidReviewState},
+		{name: "approved cannot go back to pending",
+			states:  []domain.ReviewState{domain.ReviewApproved, domain.ReviewPending},
+			wantErr: &app.ErrInvalidReviewTransition{From: domain.ReviewApproved, To: domain.ReviewPending}},
+		{name: "dismissed cannot be approved",
+			states:  []domain.ReviewState{domain.ReviewDismissed, domain.ReviewApproved},
+			wantErr: &app.ErrInvalidReviewTransition{From: domain.ReviewDismissed, To: domain.ReviewApproved}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t, team("backend", domain.StrategyRandom, 1, member("author", 1), member("b", 1)))
+			pr := createPRs(t, svc, "pr", "author", 1)[0]
+
+			var err error
+			for i, state := range tt.states {
+				pr, err = svc.SubmitReview(as(domain.RoleMember, "b"), pr.ID, "b", state, 0)
+				if err != nil && i < len(tt.states)-1 {
+					t.Fatalf("state %s: %v", state, err)
+				}
+			}
+
+			var transition *app.ErrInvalidReviewTransition
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				if got := pr.ReviewStates["b"]; got != tt.states[len(tt.states)-1] {
+					t.Errorf("review state %s, want %s", got, tt.states[len(tt.states)-1])
+				}
+			case *app.ErrInvalidReviewTransition:
+				if !errors.As(err, &transition) || *transition != *want {
+					t.Errorf("got %v, want %v", err, want)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("got %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitReviewRequiresOpenAssignedPR(t *testing.T) {
+	svc := newService(t, team("backend", domain.StrategyRandom, 1, member("author", 1), member("b", 1), member("c", 1)))
+	prs := createPRs(t, svc, "pr", "author", 2)
+	open, closed := prs[0], prs[1]
+	if _, err := svc.ClosePullRequest(adminCtx, closed.ID, 0); err != nil {
+		t.Fatalf("close pr: %v", err)
+	}
+	outsider := "b"
+	if slices.Contains(open.AssignedReviewers, "b") {
+		outsider = "c"
+	}
+
+	tests := []struct {
+		name       string
+		prID       string
+		reviewerID string
+		ifMatch    int
+		wantErr    error
+	}{
+		{"closed pull request", closed.ID, closed.AssignedReviewers[0], 0, app.ErrPRNotOpen},
+		{"reviewer is not assigned", open.ID, outsider, 0, app.ErrReviewerNotAssigned},
+		{"stale version", open.ID, open.AssignedReviewers[0], open.Version + 1, app.ErrVersionConflict},
+		{"missing pull request", "missing", open.AssignedReviewers[0], 0, app.ErrPRNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SubmitReview(adminCtx, tt.prID, tt.reviewerID, domain.ReviewApproved, tt.ifMatch)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// ReviewerStats возвращает нагрузку ревьюеров за период filter, по PR команды filter.TeamName, если она задана.
+// Возвращает ErrNotFound, если команды нет, и ErrInvalidStatsPeriod, если период заканчивается не позже начала.
+func (s *Service) ReviewerStats(ctx context.Context, filter domain.StatsFilter) ([]domain.ReviewerStats, error) {
+	if err := s.validateStatsFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+	return s.repo.ReviewerStats(ctx, filter)
+}
+
+// TeamStats возвращает статистику PR, созданных за период filter, по всем командам или только по filter.TeamName.
+// Возвращает ErrNotFound, если команды нет, и ErrInvalidStatsPeriod, если период заканчивается не позже начала.
+func (s *Service) TeamStats(ctx context.Context, filter domain.StatsFilter) ([]domain.TeamStats, error) {
+	if err := s.validateStatsFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+	return s.repo.TeamStats(ctx, filter)
+}
+
+// validateStatsFilter проверяет период и существование команды.
+func (s *Service) validateStatsFilter(ctx context.Context, filter domain.StatsFilter) error {
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return ErrInvalidStatsPeriod
+	}
+	if filter.TeamName != "" {
+		if _, err := s.repo.GetTeamByName(ctx, filter.TeamName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// statusAccessReason - пояснение отказа в смене статуса PR.
+const statusAccessReason = "only the author, the team lead, bots and admins can change the pull request status"
+
+// MarkReady переводит черновик в OPEN и назначает ревьюеров по правилам команды.
+func (s *Service) MarkReady(ctx context.Context, prID string, ifMatch int) (*domain.PullRequest, error) {
+	return s.openWithReviewers(ctx, prID, domain.StatusDraft, ifMatch)
+}
+
+// ReopenPullRequest возвращает закрытый PR в OPEN и заново назначает ревьюеров.
+func (s *Service) ReopenPullRequest(ctx context.Context, prID string, ifMatch int) (*domain.PullRequest, error) {
+	return s.openWithReviewers(ctx, prID, domain.StatusClosed, ifMatch)
+}
+
+// ClosePullRequest закрывает PR без слияния и снимает с него всех ревьюеров.
+func (s *Service) ClosePullRequest(ctx context.Context, prID string, ifMatch int) (*domain.PullRequest, error) {
+	pr, err := s.repo.GetPullRequestByID(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requirePRAccess(ctx, pr, true, statusAccessReason); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(pr, ifMatch); err != nil {
+		return nil, err
+	}
+	if !pr.Status.CanTransitionTo(domain.StatusClosed) {
+		return nil, &ErrInvalidTransition{From: pr.Status, To: domain.StatusClosed}
+	}
+
+	events := append([]domain.PREvent{newEvent(ctx, prID, domain.EventClosed)},
+		reviewerEvents(ctx, prID, domain.EventReviewerRemoved, pr.AssignedReviewers)...)
+	return s.repo.UpdatePullRequestStatus(ctx, prID, pr.Version, domain.StatusClosed, []string{}, events...)
+}
+
+// openWithReviewers переводит PR из статуса from в OPEN с новым набором ревьюеров,
+// первыми из которых назначаются владельцы измененных в PR файлов.
+func (s *Service) openWithReviewers(ctx context.Context, prID string, from domain.PRStatus, ifMatch int) (*domain.PullRequest, error) {
+	pr, err := s.repo.GetPullRequestByID(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requirePRAccess(ctx, pr, true, statusAccessReason); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(pr, ifMatch); err != nil {
+		return nil, err
+	}
+	if pr.Status != from || !pr.Status.CanTransitionTo(domain.StatusOpen) {
+		return nil, &ErrInvalidTransition{From: pr.Status, To: domain.StatusOpen}
+	}
+
+	team, err := s.prTeam(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	owners, others, err := s.assignReviewers(ctx, team, pr.AuthorID, pr.ChangedFiles, team.MaxReviewers)
+	if err != nil {
+		return nil, err
+	}
+	reviewers := append(append([]string{}, owners...), others...)
+
+	eventType := domain.EventReady
+	if from == domain.StatusClosed {
+		eventType = domain.EventReopened
+	}
+	events := append([]domain.PREvent{newEvent(ctx, prID, eventType)}, ownerEvents(ctx, prID, owners, others)...)
+	return s.repo.UpdatePullRequestStatus(ctx, prID, pr.Version, domain.StatusOpen, reviewers, events...)
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+// tokenPrefix отличает секреты токенов сервиса от других секретов, например при поиске утечек в коде.
+const tokenPrefix = "mpr_"
+
+// bootstrapTokenName - имя токена администратора, созданного из ADMIN_TOKEN.
+const bootstrapTokenName = "bootstrap admin"
+
+// hashToken возвращает SHA-256 секрета в hex: в хранилище попадает только он.
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTokenSecret генерирует секрет токена из 32 случайных байт.
+func newTokenSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Authenticate возвращает токен по его секрету. Возвращает ErrUnauthenticated, если токена нет,
+// он отозван или истек.
+func (s *Service) Authenticate(ctx context.Context, secret string) (domain.APIToken, error) {
+	token, err := s.repo.GetAPITokenByHash(ctx, hashToken(secret))
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return domain.APIToken{}, ErrUnauthenticated
+		}
+		return domain.APIToken{}, err
+	}
+	if !token.Active(time.Now()) {
+		return domain.APIToken{}, ErrUnauthenticated
+	}
+	return token, nil
+}
+
+// CreateAPIToken выпускает токен и возвращает его вместе с секретом. Секрет больше нигде не хранится,
+// поэтому показать его можно только сейчас. Доступно только администраторам.
+// Возвращает ErrInvalidAPIToken, если параметры токена некорректны, и ErrUserNotFound, если пользователя нет.
+func (s *Service) CreateAPIToken(ctx context.Context, token domain.APIToken) (domain.APIToken, string, error) {
+	if err := s.requireAdmin(ctx, "only admins can create api tokens"); err != nil {
+		return domain.APIToken{}, "", err
+	}
+
+	token.Name = strings.TrimSpace(token.Name)
+	switch {
+	case token.Name == "":
+		return domain.APIToken{}, "", fmt.Errorf("%w: name is required", ErrInvalidAPIToken)
+	case !token.Role.Valid():
+		return domain.APIToken{}, "", fmt.Errorf("%w: unknown role %q", ErrInvalidAPIToken, token.Role)
+	case token.UserID == "" && (token.Role == domain.RoleTeamLead || token.Role == domain.RoleMember):
+		return domain.APIToken{}, "", fmt.Errorf("%w: role %s requires user_id", ErrInvalidAPIToken, token.Role)
+	case token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()):
+		return domain.APIToken{}, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIToken)
+	}
+
+	secret, err := newTokenSecret()
+	if err != nil {
+		return domain.APIToken{}, "", err
+	}
+	token.RevokedAt = nil
+	created, err := s.repo.CreateAPIToken(ctx, token, hashToken(secret))
+	if err != nil {
+		return domain.APIToken{}, "", err
+	}
+	return created, secret, nil
+}
+
+// ListAPITokens возвращает токены пользователя userID, а при пустом userID - все токены.
+// Доступно только администраторам.
+func (s *Service) ListAPITokens(ctx context.Context, userID string) ([]domain.APIToken, error) {
+	if err := s.requireAdmin(ctx, "only admins can list api tokens"); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAPITokens(ctx, userID)
+}
+
+// RevokeAPIToken отзывает токен. Администратор может отозвать любой токен, остальные - токены своего пользователя.
+// Возвращает ErrTokenNotFound, если токена нет.
+func (s *Service) RevokeAPIToken(ctx context.Context, tokenID int64) (domain.APIToken, error) {
+	token, err := s.repo.GetAPIToken(ctx, tokenID)
+	if err != nil {
+		return domain.APIToken{}, err
+	}
+	if token.UserID == "" {
+		err = s.requireAdmin(ctx, "only admins can revoke api tokens without a user")
+	} else {
+		err = s.requireSelf(ctx, token.UserID, "only admins can revoke other users' api tokens")
+	}
+	if err != nil {
+		return domain.APIToken{}, err
+	}
+	return s.repo.RevokeAPIToken(ctx, tokenID, time.Now())
+}
+
+// EnsureBootstrapToken создает токен администратора с секретом secret, если его еще нет.
+// Так первый администратор получает доступ к API и может выпустить остальные токены.
+// Отозванный токен не восстанавливается.
+func (s *Service) EnsureBootstrapToken(ctx context.Context, secret string) error {
+	hash := hashToken(secret)
+	_, err := s.repo.GetAPITokenByHash(ctx, hash)
+	if err == nil || !errors.Is(err, ErrTokenNotFound) {
+		return err
+	}
+	_, err = s.repo.CreateAPIToken(ctx, domain.APIToken{Name: bootstrapTokenName, Role: domain.RoleAdmin}, hash)
+	return err
+}
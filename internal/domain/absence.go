@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// Absence - период отсутствия пользователя [StartsAt, EndsAt), в течение которого
+// он не выбирается ревьюером, даже если отмечен активным.
+type Absence struct {
+	ID        int64     `json:"absence_id"`
+	UserID    string    `json:"user_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Covers сообщает, приходится ли момент at на период отсутствия.
+func (a *Absence) Covers(at time.Time) bool {
+	return !at.Before(a.StartsAt) && at.Before(a.EndsAt)
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"path"
+	"strings"
+)
+
+// CodeOwnerRule - правило владения кодом в стиле CODEOWNERS: файлы, подходящие под Pattern,
+// принадлежат пользователям Owners.
+//
+// Шаблон без "/" в середине (например, "*.go" или "docs/") ищется на любой глубине, а шаблон
+// с "/" в начале или середине отсчитывается от корня репозитория. "**" совпадает с любым числом
+// каталогов, "/" в конце ограничивает шаблон каталогами. Совпадение с каталогом распространяется
+// на все файлы внутри него.
+type CodeOwnerRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+// Valid сообщает, корректен ли синтаксис шаблона правила.
+func (r *CodeOwnerRule) Valid() bool {
+	pattern := strings.Trim(r.Pattern, "/")
+	if pattern == "" {
+		return false
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches сообщает, подходит ли файл filePath под шаблон правила.
+func (r *CodeOwnerRule) Matches(filePath string) bool {
+	pattern := strings.Trim(r.Pattern, "/")
+	if pattern == "" {
+		return false
+	}
+	dirOnly := strings.HasSuffix(r.Pattern, "/")
+	segments := strings.Split(pattern, "/")
+	if !strings.HasPrefix(r.Pattern, "/") && len(segments) == 1 {
+		segments = append([]string{"**"}, segments...)
+	}
+
+	// Шаблон может совпасть с самим файлом или с любым из его каталогов.
+	parts := strings.Split(strings.Trim(filePath, "/"), "/")
+	for n := 1; n <= len(parts); n++ {
+		if dirOnly && n == len(parts) {
+			break
+		}
+		if matchSegments(segments, parts[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments сопоставляет сегменты шаблона с сегментами пути; "**" поглощает любое их число.
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], parts[0])
+	return ok && matchSegments(pattern[1:], parts[1:])
+}
+
+// CodeOwners возвращает владельцев файлов files без повторов в порядке первого упоминания.
+// Для каждого файла, как в CODEOWNERS, действует последнее подходящее правило.
+func CodeOwners(rules []CodeOwnerRule, files []string) []string {
+	owners := []string{}
+	seen := make(map[string]struct{})
+	for _, file := range files {
+		for i := len(rules) - 1; i >= 0; i-- {
+			if !rules[i].Matches(file) {
+				continue
+			}
+			for _, owner := range rules[i].Owners {
+				if _, ok := seen[owner]; !ok {
+					seen[owner] = struct{}{}
+					owners = append(owners, owner)
+				}
+			}
+			break
+		}
+	}
+	return owners
+}
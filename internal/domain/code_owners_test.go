@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCodeOwnerRuleMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/app/service.go", true},
+		{"*.go", "README.md", false},
+		{"docs/", "docs/api.md", true},
+		{"docs/", "internal/docs/guide.md", true},
+		{"docs/", "docs", false},
+		{"docs", "docs", true},
+		{"/docs/", "internal/docs/guide.md", false},
+		{"/docs/", "docs/api/openapi.yml", true},
+		{"internal/app", "internal/app/service.go", true},
+		{"internal/app", "cmd/internal/app/main.go", false},
+		{"internal/**/repo.go", "internal/repo.go", true},
+		{"internal/**/repo.go", "internal/repository/sqlite/repo.go", true},
+		{"internal/**/repo.go", "pkg/repository/repo.go", false},
+		{"**/migrations/*.sql", "migrations/0001.up.sql", true},
+		{"**/migrations/*.sql", "db/migrations/0001.up.sql", true},
+		{"/", "main.go", false},
+	}
+	for _, tt := range tests {
+		rule := CodeOwnerRule{Pattern: tt.pattern}
+		if got := rule.Matches(tt.file); got != tt.want {
+			t.Errorf("%q.Matches(%q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestCodeOwnerRuleValid(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"*.go", true},
+		{"/docs/", true},
+		{"internal/**/repo.go", true},
+		{"", false},
+		{"/", false},
+		{"[", false},
+	}
+	for _, tt := range tests {
+		rule := CodeOwnerRule{Pattern: tt.pattern}
+		if got := rule.Valid(); got != tt.want {
+			t.Errorf("%q.Valid() = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestCodeOwners(t *testing.T) {
+	rules := []CodeOwnerRule{
+		{Pattern: "*", Owners: []string{"lead"}},
+		{Pattern: "*.go", Owners: []string{"gopher", "lead"}},
+		{Pattern: "/internal/app/", Owners: []string{"app-owner"}},
+	}
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"no files", nil, []string{}},
+		{"catch-all rule", []string{"README.md"}, []string{"lead"}},
+		{"later rule wins", []string{"main.go"}, []string{"gopher", "lead"}},
+		{"last matching rule wins", []string{"internal/app/service.go"}, []string{"app-owner"}},
+		{"owners without repeats in order of first mention", []string{"main.go", "internal/app/service.go", "README.md"}, []string{"gopher", "lead", "app-owner"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOwners(rules, tt.files); !slices.Equal(got, tt.want) {
+				t.Errorf("CodeOwners() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
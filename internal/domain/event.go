@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// PREventType - тип события в истории PR.
+type PREventType string
+
+const (
+	EventCreated          PREventType = "created"
+	EventReviewerAssigned PREventType = "reviewer_assigned"
+	EventReviewerRemoved  PREventType = "reviewer_removed"
+	EventReviewerReplaced PREventType = "reviewer_replaced"
+	EventReviewSubmitted  PREventType = "review_submitted"
+	EventReady            PREventType = "ready"
+	EventMerged           PREventType = "merged"
+	EventClosed           PREventType = "closed"
+	EventReopened         PREventType = "reopened"
+)
+
+// PREvent - запись в журнале изменений PR.
+type PREvent struct {
+	ID            int64
+	PullRequestID string
+	Type          PREventType
+	ActorID       string // Кто выполнил действие
+	ReviewerID    string // Ревьюер, которого касается событие (для замены - новый)
+	OldReviewerID string // Замененный ревьюер
+	Details       string // Статус PR или решение ревьюера
+	CreatedAt     time.Time
+}
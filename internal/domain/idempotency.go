@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord - сохраненный результат запроса с заголовком Idempotency-Key.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string // sha256 от метода, пути и тела запроса
+	StatusCode  int    // 0, пока исходный запрос ещё обрабатывается
+	ContentType string // Заголовки ответа, которые повторяются вместе с телом; пустые не отправляются
+	ETag        string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Completed сообщает, что ответ на исходный запрос уже сохранен.
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -5,16 +5,65 @@ import "time"
 type PRStatus string
 
 const (
+	StatusDraft  PRStatus = "DRAFT"
 	StatusOpen   PRStatus = "OPEN"
 	StatusMerged PRStatus = "MERGED"
+	StatusClosed PRStatus = "CLOSED"
 )
 
+// statusTransitions описывает допустимые переходы между статусами PR.
+// MERGED - конечный статус.
+var statusTransitions = map[PRStatus][]PRStatus{
+	StatusDraft:  {StatusOpen, StatusClosed},
+	StatusOpen:   {StatusMerged, StatusClosed},
+	StatusClosed: {StatusOpen},
+	StatusMerged: {},
+}
+
+// Valid сообщает, что статус PR известен.
+func (s PRStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo сообщает, можно ли перевести PR из текущего статуса в next.
+func (s PRStatus) CanTransitionTo(next PRStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type PullRequest struct {
-	ID                string     `json:"pull_request_id"`
-	Name              string     `json:"pull_request_name"`
-	AuthorID          string     `json:"author_id"`
-	Status            PRStatus   `json:"status"`
-	AssignedReviewers []string   `json:"assigned_reviewers"` // Список ID пользователей
-	CreatedAt         time.Time  `json:"createdAt"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	ID                string                 `json:"pull_request_id"`
+	Name              string                 `json:"pull_request_name"`
+	AuthorID          string                 `json:"author_id"`
+	TeamName          string                 `json:"team_name"` // Команда, из которой назначаются ревьюеры
+	Status            PRStatus               `json:"status"`
+	ChangedFiles      []string               `json:"changed_files"`      // Пути измененных файлов для выбора владельцев кода
+	AssignedReviewers []string               `json:"assigned_reviewers"` // Список ID пользователей
+	ReviewStates      map[string]ReviewState `json:"review_states"`      // Состояние ревью по ID ревьюера
+	RequiredReviewers int                    `json:"required_reviewers"` // Минимум ревьюеров по настройкам команды
+	CreatedAt         time.Time              `json:"createdAt"`
+	MergedAt          *time.Time             `json:"mergedAt,omitempty"`
+	ClosedAt          *time.Time             `json:"closedAt,omitempty"`
+	ForceMerged       bool                   `json:"force_merged"` // Слит в обход политики слияния
+	Version           int                    `json:"version"`      // Растет при каждом изменении PR
+}
+
+// ReviewerUpdate - новый состав ревьюеров PR, который применяется, только если версия PR
+// всё ещё равна Version. Оставшиеся ревьюеры сохраняют состояние ревью.
+type ReviewerUpdate struct {
+	PullRequestID string
+	Version       int
+	Reviewers     []string
+	Events        []PREvent
+}
+
+// UnderReviewed сообщает, что PR получил меньше ревьюеров, чем требует команда.
+// Черновику ревьюеры не назначаются, поэтому он недоукомплектованным не считается.
+func (pr *PullRequest) UnderReviewed() bool {
+	return pr.Status != StatusDraft && len(pr.AssignedReviewers) < pr.RequiredReviewers
 }
@@ -0,0 +1,73 @@
+package domain
+
+import "time"
+
+// PRSort определяет порядок выдачи списков PR. При равном времени создания PR упорядочиваются по ID.
+type PRSort string
+
+const (
+	SortCreatedDesc PRSort = "created_desc" // Сначала новые
+	SortCreatedAsc  PRSort = "created_asc"  // Сначала старые
+)
+
+// Valid сообщает, что порядок сортировки известен.
+func (s PRSort) Valid() bool {
+	return s == SortCreatedDesc || s == SortCreatedAsc
+}
+
+// PRCursor - позиция в списке PR: ключ сортировки последнего выданного PR.
+// Следующая страница начинается сразу после него.
+type PRCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ReviewerPRFilter - условия выборки PR, на которые назначен ревьюер.
+type ReviewerPRFilter struct {
+	ReviewerID  string
+	Statuses    []PRStatus // Пустой список - только открытые PR
+	PendingOnly bool       // Только PR, по которым ревьюер ещё не принял решение
+	AuthorID    string
+	CreatedFrom *time.Time // Включительно
+	CreatedTo   *time.Time // Не включительно
+	Sort        PRSort
+	After       *PRCursor
+	Limit       int // 0 - без ограничения
+}
+
+// PRFilter - условия поиска PR. Пустые поля выборку не ограничивают.
+type PRFilter struct {
+	AuthorID     string
+	TeamName     string
+	Statuses     []PRStatus
+	ReviewerID   string     // Назначенный ревьюер
+	NameContains string     // Подстрока названия без учета регистра
+	CreatedFrom  *time.Time // Включительно
+	CreatedTo    *time.Time // Не включительно
+	MergedFrom   *time.Time // Включительно
+	MergedTo     *time.Time // Не включительно
+	Sort         PRSort
+	After        *PRCursor
+	Limit        int // 0 - без ограничения
+}
+
+// PRPage - страница списка PR. Next задан, если за ней есть ещё PR.
+type PRPage struct {
+	PullRequests []*PullRequest
+	Next         *PRCursor
+}
+
+// NewPRPage собирает страницу из PR, выбранных с запасом на один сверх limit:
+// лишний PR не попадает на страницу, а лишь показывает, что следующая страница есть.
+func NewPRPage(prs []*PullRequest, limit int) PRPage {
+	page := PRPage{PullRequests: prs}
+	if page.PullRequests == nil {
+		page.PullRequests = []*PullRequest{}
+	}
+	if limit > 0 && len(prs) > limit {
+		page.PullRequests = prs[:limit]
+		last := prs[limit-1]
+		page.Next = &PRCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return page
+}
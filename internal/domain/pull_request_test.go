@@ -0,0 +1,47 @@
+package domain
+
+import "testing"
+
+func TestPRStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to PRStatus
+		want     bool
+	}{
+		{StatusDraft, StatusOpen, true},
+		{StatusDraft, StatusClosed, true},
+		{StatusDraft, StatusMerged, false},
+		{StatusOpen, StatusMerged, true},
+		{StatusOpen, StatusClosed, true},
+		{StatusOpen, StatusDraft, false},
+		{StatusOpen, StatusOpen, false},
+		{StatusClosed, StatusOpen, true},
+		{StatusClosed, StatusMerged, false},
+		{StatusMerged, StatusOpen, false},
+		{StatusMerged, StatusClosed, false},
+		{PRStatus("UNKNOWN"), StatusOpen, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestUnderReviewed(t *testing.T) {
+	tests := []struct {
+		name string
+		pr   PullRequest
+		want bool
+	}{
+		{"enough reviewers", PullRequest{Status: StatusOpen, AssignedReviewers: []string{"a", "b"}, RequiredReviewers: 2}, false},
+		{"missing reviewer", PullRequest{Status: StatusOpen, AssignedReviewers: []string{"a"}, RequiredReviewers: 2}, true},
+		{"draft without reviewers", PullRequest{Status: StatusDraft, RequiredReviewers: 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pr.UnderReviewed(); got != tt.want {
+				t.Errorf("UnderReviewed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
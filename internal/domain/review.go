@@ -0,0 +1,39 @@
+package domain
+
+// ReviewState - решение назначенного ревьюера по PR.
+type ReviewState string
+
+const (
+	ReviewPending          ReviewState = "PENDING"
+	ReviewApproved         ReviewState = "APPROVED"
+	ReviewChangesRequested ReviewState = "CHANGES_REQUESTED"
+	ReviewDismissed        ReviewState = "DISMISSED"
+)
+
+// reviewTransitions описывает допустимые переходы между состояниями ревью.
+// Из DISMISSED можно вернуться только в PENDING (повторный запрос ревью).
+var reviewTransitions = map[ReviewState][]ReviewState{
+	ReviewPending:          {ReviewApproved, ReviewChangesRequested, ReviewDismissed},
+	ReviewApproved:         {ReviewChangesRequested, ReviewDismissed},
+	ReviewChangesRequested: {ReviewApproved, ReviewDismissed},
+	ReviewDismissed:        {ReviewPending},
+}
+
+// Valid сообщает, что состояние ревью известно.
+func (s ReviewState) Valid() bool {
+	_, ok := reviewTransitions[s]
+	return ok
+}
+
+// CanTransitionTo сообщает, можно ли перейти в состояние next. Повтор того же состояния допустим.
+func (s ReviewState) CanTransitionTo(next ReviewState) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range reviewTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "testing"
+
+func TestReviewStateCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to ReviewState
+		want     bool
+	}{
+		{ReviewPending, ReviewApproved, true},
+		{ReviewPending, ReviewChangesRequested, true},
+		{ReviewPending, ReviewDismissed, true},
+		{ReviewPending, ReviewPending, true},
+		{ReviewApproved, ReviewChangesRequested, true},
+		{ReviewApproved, ReviewDismissed, true},
+		{ReviewApproved, ReviewApproved, true},
+		{ReviewApproved, ReviewPending, false},
+		{ReviewChangesRequested, ReviewApproved, true},
+		{ReviewChangesRequested, ReviewPending, false},
+		{ReviewDismissed, ReviewPending, true},
+		{ReviewDismissed, ReviewApproved, false},
+		{ReviewDismissed, ReviewChangesRequested, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestReviewStateValid(t *testing.T) {
+	for _, state := range []ReviewState{ReviewPending, ReviewApproved, ReviewChangesRequested, ReviewDismissed} {
+		if !state.Valid() {
+			t.Errorf("%s.Valid() = false, want true", state)
+		}
+	}
+	if ReviewState("LGTM").Valid() {
+		t.Error("LGTM.Valid() = true, want false")
+	}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// StatsFilter - условия расчета статистики. Пустые поля выборку не ограничивают.
+type StatsFilter struct {
+	TeamName string     // Только PR этой команды
+	From     *time.Time // Включительно
+	To       *time.Time // Не включительно
+}
+
+// ReviewerStats - нагрузка ревьюера по назначениям, сделанным за период.
+type ReviewerStats struct {
+	UserID            string
+	Assignments       int            // Назначения ревьюером по журналу событий, включая те, с которых его потом сняли
+	OpenReviews       int            // Назначения за период, которые он всё ещё держит на открытых PR
+	ReassignedAway    int            // Замены ревьюера другим за период
+	MedianTimeToMerge *time.Duration // Медиана времени от назначения до слияния PR; nil, если слитых PR нет
+}
+
+// TeamStats - PR команды, созданные за период.
+type TeamStats struct {
+	TeamName       string
+	OpenPRs        int
+	MergedPRs      int
+	AvgTimeToMerge *time.Duration // Среднее время от создания до слияния PR; nil, если слитых PR нет
+}
+
+// MedianDuration возвращает медиану длительностей; при четном их числе - среднее двух центральных.
+// Для пустого списка возвращает nil.
+func MedianDuration(durations []time.Duration) *time.Duration {
+	if len(durations) == 0 {
+		return nil
+	}
+	sorted := slices.Sorted(slices.Values(durations))
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	return &median
+}
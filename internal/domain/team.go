@@ -1,6 +1,69 @@
 package domain
 
+// ReviewerStrategy определяет способ выбора ревьюеров в команде.
+type ReviewerStrategy string
+
+const (
+	StrategyRandom      ReviewerStrategy = "random"
+	StrategyRoundRobin  ReviewerStrategy = "round_robin"
+	StrategyLeastLoaded ReviewerStrategy = "least_loaded"
+	StrategyWeighted    ReviewerStrategy = "weighted"
+)
+
+// MergePolicy определяет условия, при которых PR команды можно слить.
+type MergePolicy string
+
+const (
+	MergePolicyNone         MergePolicy = "none"          // Слияние без условий
+	MergePolicyAllApproved  MergePolicy = "all_approved"  // Все назначенные ревьюеры, кроме DISMISSED, одобрили (нужно хотя бы одно одобрение)
+	MergePolicyMinApprovals MergePolicy = "min_approvals" // Не меньше N одобрений и нет CHANGES_REQUESTED
+)
+
+// ReviewerPoolMode определяет, когда пул дополняет кандидатов в ревьюеры из самой команды.
+type ReviewerPoolMode string
+
+const (
+	PoolModeAlways   ReviewerPoolMode = "always"   // Участники пула всегда среди кандидатов
+	PoolModeFallback ReviewerPoolMode = "fallback" // Пул используется, только если кандидатов команды не хватает
+)
+
+// ReviewerPool - дополнительный источник ревьюеров команды: другая команда (TeamName)
+// или явный список пользователей (UserIDs). Задается ровно одно из полей.
+type ReviewerPool struct {
+	Mode     ReviewerPoolMode `json:"mode"`
+	TeamName string           `json:"team_name,omitempty"`
+	UserIDs  []string         `json:"user_ids,omitempty"`
+}
+
+// Значения настроек команды по умолчанию.
+const (
+	DefaultMinReviewers = 2
+	DefaultMaxReviewers = 2
+)
+
+// TeamSettings - настройки назначения ревьюеров и слияния PR в команде.
+type TeamSettings struct {
+	ReviewerStrategy  ReviewerStrategy `json:"reviewer_strategy"`
+	MinReviewers      int              `json:"min_reviewers"`
+	MaxReviewers      int              `json:"max_reviewers"`
+	MergePolicy       MergePolicy      `json:"merge_policy"`
+	RequiredApprovals int              `json:"required_approvals"` // Для политики min_approvals
+}
+
+// DefaultTeamSettings возвращает настройки для новой команды.
+func DefaultTeamSettings() TeamSettings {
+	return TeamSettings{
+		ReviewerStrategy: StrategyRandom,
+		MinReviewers:     DefaultMinReviewers,
+		MaxReviewers:     DefaultMaxReviewers,
+		MergePolicy:      MergePolicyNone,
+	}
+}
+
 type Team struct {
-	Name    string `json:"team_name"`
-	Members []User `json:"members"`
+	Name string `json:"team_name"`
+	TeamSettings
+	Members       []User          `json:"members"`
+	ReviewerPools []ReviewerPool  `json:"reviewer_pools"`
+	CodeOwners    []CodeOwnerRule `json:"code_owners"` // Правила в порядке задания, последнее совпавшее важнее
 }
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// Role определяет, какие операции доступны владельцу токена API.
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"     // Любые операции
+	RoleTeamLead Role = "team-lead" // Как member, а также управление командами пользователя и их PR
+	RoleMember   Role = "member"    // Свои PR, ревью, активность и отсутствия
+	RoleBot      Role = "bot"       // Интеграции: создание PR и смена их статуса от имени любого автора
+)
+
+// Valid сообщает, известна ли роль.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleTeamLead, RoleMember, RoleBot:
+		return true
+	}
+	return false
+}
+
+// APIToken - токен доступа к API. Секрет токена не хранится, хранится только его хеш.
+type APIToken struct {
+	ID        int64
+	Name      string // Описание для людей, например "ci-bot"
+	Role      Role
+	UserID    string // Пользователь, от имени которого действует токен; для team-lead и member обязателен
+	CreatedAt time.Time
+	ExpiresAt *time.Time // nil - бессрочный
+	RevokedAt *time.Time
+}
+
+// Active сообщает, можно ли пользоваться токеном в момент at.
+func (t *APIToken) Active(at time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || at.Before(*t.ExpiresAt))
+}
+
+// Actor возвращает автора изменений для журнала событий: ID пользователя токена,
+// а для токена без пользователя - token:<ID>.
+func (t *APIToken) Actor() string {
+	if t.UserID != "" {
+		return t.UserID
+	}
+	return "token:" + strconv.FormatInt(t.ID, 10)
+}
@@ -1,8 +1,20 @@
 package domain
 
 type User struct {
-	ID       string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	ID           string   `json:"user_id"`
+	Username     string   `json:"username"`
+	TeamName     string   `json:"team_name"` // Основная команда, используется по умолчанию при создании PR
+	Teams        []string `json:"teams"`     // Все команды пользователя
+	IsActive     bool     `json:"is_active"`
+	ReviewWeight int      `json:"review_weight"` // Вес для стратегии weighted
+}
+
+// InTeam сообщает, состоит ли пользователь в команде.
+func (u *User) InTeam(teamName string) bool {
+	for _, team := range u.Teams {
+		if team == teamName {
+			return true
+		}
+	}
+	return false
 }
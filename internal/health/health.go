@@ -0,0 +1,103 @@
+// Package health проверяет готовность сервиса принимать трафик: доступность зависимостей
+// и то, что сервис не завершается.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Статусы проверок и сервиса в целом.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining" // Сервис завершается и не должен получать новые запросы
+)
+
+// checkTimeout ограничивает время одной проверки, чтобы зависшая зависимость не задерживала ответ.
+const checkTimeout = 2 * time.Second
+
+// Check проверяет зависимость и возвращает ошибку, если она недоступна.
+type Check func(ctx context.Context) error
+
+// CheckResult - результат проверки одной зависимости.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// Report - результат проверки готовности. Checks пуст, пока сервис завершается.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready сообщает, что сервис готов принимать запросы.
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker хранит проверки зависимостей. Проверки добавляются при запуске, до начала обработки запросов.
+type Checker struct {
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add добавляет проверку зависимости name.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain переводит сервис в состояние завершения: с этого момента он не готов принимать запросы.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready выполняет все проверки параллельно. Сервис готов, если все зависимости доступны и он не завершается.
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(c.checks))}
+	if c.draining.Load() {
+		report.Status = StatusDraining
+		return report
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(checkCtx)
+			result := CheckResult{Status: StatusOK, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				result.Status = StatusUnavailable
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if err != nil {
+				report.Status = StatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
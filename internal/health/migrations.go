@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MigrationVersion возвращает версию схемы, записанную golang-migrate, и признак незавершенной миграции.
+type MigrationVersion func(ctx context.Context) (version uint, dirty bool, err error)
+
+// LatestMigration возвращает номер последней миграции в каталоге dir по именам файлов вида 000001_name.up.sql.
+func LatestMigration(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("read migrations: %w", err)
+	}
+	var latest uint
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		number, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(number, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration file %q has no version", name)
+		}
+		latest = max(latest, uint(version))
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations in %q", dir)
+	}
+	return latest, nil
+}
+
+// MigrationCheck проверяет, что схема базы мигрирована до версии want и последняя миграция завершена.
+func MigrationCheck(current MigrationVersion, want uint) Check {
+	return func(ctx context.Context) error {
+		version, dirty, err := current(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed and left the schema dirty", version)
+		}
+		if version != want {
+			return fmt.Errorf("schema version is %d, want %d", version, want)
+		}
+		return nil
+	}
+}
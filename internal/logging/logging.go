@@ -0,0 +1,115 @@
+// Package logging настраивает структурированные логи на log/slog и связывает записи с запросом:
+// атрибуты, добавленные в контекст запроса, попадают в каждую запись, сделанную с этим контекстом
+// через методы *Context (InfoContext, ErrorContext и т.д.).
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Форматы логов для LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New создает логгер уровня level (debug, info, warn, error) в формате format (json, text).
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// contextHandler добавляет к записи атрибуты запроса из контекста. Атрибуты самой записи важнее:
+// атрибут запроса с тем же ключом не добавляется.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs, ok := ctx.Value(attrsKey{}).(*requestAttrs)
+	if !ok {
+		return h.Handler.Handle(ctx, record)
+	}
+
+	keys := make(map[string]struct{}, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		keys[a.Key] = struct{}{}
+		return true
+	})
+	for _, attr := range attrs.list() {
+		if _, ok := keys[attr.Key]; !ok {
+			record.AddAttrs(attr)
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type attrsKey struct{}
+
+// requestAttrs - атрибуты запроса. Они дополняются по ходу обработки, поэтому защищены мьютексом.
+type requestAttrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+func (a *requestAttrs) list() []slog.Attr {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]slog.Attr{}, a.attrs...)
+}
+
+// WithRequest возвращает контекст запроса с атрибутами args: парами ключ-значение или slog.Attr.
+func WithRequest(ctx context.Context, args ...any) context.Context {
+	attrs := &requestAttrs{}
+	ctx = context.WithValue(ctx, attrsKey{}, attrs)
+	AddAttrs(ctx, args...)
+	return ctx
+}
+
+// AddAttrs добавляет атрибуты к запросу из ctx, заменяя атрибуты с теми же ключами. Они попадают во все
+// следующие записи с любым контекстом этого запроса. Вне запроса, начатого WithRequest, ничего не делает.
+func AddAttrs(ctx context.Context, args ...any) {
+	attrs, ok := ctx.Value(attrsKey{}).(*requestAttrs)
+	if !ok {
+		return
+	}
+
+	attrs.mu.Lock()
+	defer attrs.mu.Unlock()
+	for _, attr := range slog.Group("", args...).Value.Group() {
+		i := slices.IndexFunc(attrs.attrs, func(a slog.Attr) bool { return a.Key == attr.Key })
+		if i >= 0 {
+			attrs.attrs[i] = attr
+		} else {
+			attrs.attrs = append(attrs.attrs, attr)
+		}
+	}
+}
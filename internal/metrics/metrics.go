@@ -0,0 +1,64 @@
+// Package metrics собирает метрики сервиса в формате Prometheus: HTTP-запросы, пул соединений с базой
+// и доменные события. Все метрики регистрируются в Registry, который отдает обработчик /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry - реестр метрик сервиса. Кроме метрик пакета в нем есть стандартные метрики Go-рантайма и процесса.
+var Registry = prometheus.NewRegistry()
+
+// HTTP-запросы по методу, шаблону маршрута chi и коду ответа.
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Причины замены ревьюера для метрик ReviewerReassignments и NoCandidate.
+const (
+	ReasonManual      = "manual"      // Замена через /pullRequest/reassign
+	ReasonLeftTeam    = "left_team"   // Ревьюер ушел из команды
+	ReasonDeactivated = "deactivated" // Ревьюер деактивирован
+)
+
+// Доменные события.
+var (
+	PullRequestsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pull_requests_created_total",
+		Help: "Number of created pull requests, including drafts.",
+	})
+	PullRequestsMerged = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pull_requests_merged_total",
+		Help: "Number of merged pull requests.",
+	})
+	ReviewerReassignments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reviewer_reassignments_total",
+		Help: "Number of reviewers replaced by another reviewer, by reason.",
+	}, []string{"reason"})
+	NoCandidate = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reviewer_no_candidate_total",
+		Help: "Number of times no replacement reviewer could be found, by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		PullRequestsCreated,
+		PullRequestsMerged,
+		ReviewerReassignments,
+		NoCandidate,
+	)
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector снимает статистику пула pgxpool в момент сбора метрик.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns           *prometheus.Desc
+	idleConns               *prometheus.Desc
+	constructingConns       *prometheus.Desc
+	totalConns              *prometheus.Desc
+	maxConns                *prometheus.Desc
+	acquireCount            *prometheus.Desc
+	acquireDuration         *prometheus.Desc
+	canceledAcquireCount    *prometheus.Desc
+	emptyAcquireCount       *prometheus.Desc
+	newConnsCount           *prometheus.Desc
+	maxLifetimeDestroyCount *prometheus.Desc
+	maxIdleDestroyCount     *prometheus.Desc
+}
+
+// NewPoolCollector создает коллектор метрик pgxpool_* для пула соединений с PostgreSQL.
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("pgxpool_"+name, help, nil, nil)
+	}
+	return &poolCollector{
+		pool:                    pool,
+		acquiredConns:           desc("acquired_conns", "Number of currently acquired connections."),
+		idleConns:               desc("idle_conns", "Number of currently idle connections."),
+		constructingConns:       desc("constructing_conns", "Number of connections being established."),
+		totalConns:              desc("total_conns", "Total number of connections in the pool."),
+		maxConns:                desc("max_conns", "Maximum size of the pool."),
+		acquireCount:            desc("acquire_total", "Number of successful acquires from the pool."),
+		acquireDuration:         desc("acquire_duration_seconds_total", "Total time spent on successful acquires."),
+		canceledAcquireCount:    desc("canceled_acquire_total", "Number of acquires canceled by context."),
+		emptyAcquireCount:       desc("empty_acquire_total", "Number of acquires that waited for a connection."),
+		newConnsCount:           desc("new_conns_total", "Number of connections opened."),
+		maxLifetimeDestroyCount: desc("max_lifetime_destroy_total", "Number of connections closed by MaxConnLifetime."),
+		maxIdleDestroyCount:     desc("max_idle_destroy_total", "Number of connections closed by MaxConnIdleTime."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	gauge := func(desc *prometheus.Desc, value int32) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value))
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	gauge(c.acquiredConns, stat.AcquiredConns())
+	gauge(c.idleConns, stat.IdleConns())
+	gauge(c.constructingConns, stat.ConstructingConns())
+	gauge(c.totalConns, stat.TotalConns())
+	gauge(c.maxConns, stat.MaxConns())
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroyCount, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroyCount, float64(stat.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/wsppppp/manage-pull-request/internal/repository"
+	"github.com/wsppppp/manage-pull-request/internal/repository/memory"
+	"github.com/wsppppp/manage-pull-request/internal/repository/repotest"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		return memory.New()
+	})
+}
@@ -0,0 +1,985 @@
+// Package memory реализует repository.Repository в памяти процесса.
+// Подходит для тестов и локального запуска без базы данных; данные теряются при перезапуске.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/repository"
+)
+
+// MemoryRepository хранит данные в map под одним мьютексом, поэтому безопасен для конкурентного использования.
+// Каждый метод выполняется атомарно, как транзакция в PgRepository.
+type MemoryRepository struct {
+	mu          sync.RWMutex
+	teams       map[string]domain.TeamSettings
+	users       map[string]domain.User
+	members     map[string]map[string]struct{} // Участники по командам
+	pools       map[string][]domain.ReviewerPool
+	codeOwners  map[string][]domain.CodeOwnerRule
+	prs         map[string]*domain.PullRequest
+	assignedAt  map[string]map[string]time.Time // Время назначения ревьюеров по PR
+	events      map[string][]domain.PREvent
+	nextEventID int64
+	idempotency map[string]domain.IdempotencyRecord
+	absences    map[int64]domain.Absence
+	nextAbsence int64
+	tokens      map[int64]domain.APIToken
+	tokenHashes map[string]int64 // ID токенов по хешу секрета
+	nextToken   int64
+}
+
+func New() repository.Repository {
+	return &MemoryRepository{
+		teams:       make(map[string]domain.TeamSettings),
+		users:       make(map[string]domain.User),
+		members:     make(map[string]map[string]struct{}),
+		pools:       make(map[string][]domain.ReviewerPool),
+		codeOwners:  make(map[string][]domain.CodeOwnerRule),
+		prs:         make(map[string]*domain.PullRequest),
+		assignedAt:  make(map[string]map[string]time.Time),
+		events:      make(map[string][]domain.PREvent),
+		idempotency: make(map[string]domain.IdempotencyRecord),
+		absences:    make(map[int64]domain.Absence),
+		tokens:      make(map[int64]domain.APIToken),
+		tokenHashes: make(map[string]int64),
+	}
+}
+
+func (r *MemoryRepository) CreateTeam(_ context.Context, team domain.Team) (domain.Team, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[team.Name]; ok {
+		return domain.Team{}, &app.ErrTeamExists{TeamName: team.Name}
+	}
+	if err := r.validatePools(team.ReviewerPools, team.Members); err != nil {
+		return domain.Team{}, err
+	}
+	if err := r.validateCodeOwners(team.CodeOwners, team.Members); err != nil {
+		return domain.Team{}, err
+	}
+	r.teams[team.Name] = team.TeamSettings
+	r.members[team.Name] = make(map[string]struct{}, len(team.Members))
+
+	for _, member := range team.Members {
+		r.addMember(team.Name, member)
+	}
+	r.pools[team.Name] = clonePools(team.ReviewerPools)
+	r.codeOwners[team.Name] = cloneCodeOwners(team.CodeOwners)
+	return team, nil
+}
+
+func (r *MemoryRepository) GetTeamByName(_ context.Context, teamName string) (domain.Team, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	settings, ok := r.teams[teamName]
+	if !ok {
+		return domain.Team{}, app.ErrNotFound
+	}
+
+	team := domain.Team{Name: teamName, TeamSettings: settings}
+	for userID := range r.members[teamName] {
+		team.Members = append(team.Members, r.user(userID))
+	}
+	sort.Slice(team.Members, func(i, j int) bool { return team.Members[i].ID < team.Members[j].ID })
+	team.ReviewerPools = clonePools(r.pools[teamName])
+	team.CodeOwners = cloneCodeOwners(r.codeOwners[teamName])
+	return team, nil
+}
+
+func (r *MemoryRepository) UpdateTeamSettings(_ context.Context, teamName string, settings domain.TeamSettings) (domain.TeamSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[teamName]; !ok {
+		return domain.TeamSettings{}, app.ErrNotFound
+	}
+	r.teams[teamName] = settings
+	return settings, nil
+}
+
+func (r *MemoryRepository) SetReviewerPools(_ context.Context, teamName string, pools []domain.ReviewerPool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[teamName]; !ok {
+		return app.ErrNotFound
+	}
+	if err := r.validatePools(pools, nil); err != nil {
+		return err
+	}
+	r.pools[teamName] = clonePools(pools)
+	return nil
+}
+
+func (r *MemoryRepository) SetCodeOwners(_ context.Context, teamName string, rules []domain.CodeOwnerRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[teamName]; !ok {
+		return app.ErrNotFound
+	}
+	if err := r.validateCodeOwners(rules, nil); err != nil {
+		return err
+	}
+	r.codeOwners[teamName] = cloneCodeOwners(rules)
+	return nil
+}
+
+func (r *MemoryRepository) AddTeamMember(_ context.Context, teamName string, member domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[teamName]; !ok {
+		return app.ErrNotFound
+	}
+	r.addMember(teamName, member)
+	return nil
+}
+
+func (r *MemoryRepository) RemoveTeamMember(_ context.Context, teamName, userID string, updates []domain.ReviewerUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Сначала проверяем все условия, чтобы при ошибке ничего не изменить.
+	if _, ok := r.teams[teamName]; !ok {
+		return app.ErrNotFound
+	}
+	if _, ok := r.members[teamName][userID]; !ok {
+		return app.ErrNotTeamMember
+	}
+	prs := make([]*domain.PullRequest, len(updates))
+	for i, update := range updates {
+		pr, err := r.lockedPR(update.PullRequestID, update.Version)
+		if err != nil {
+			return err
+		}
+		prs[i] = pr
+	}
+
+	delete(r.members[teamName], userID)
+	r.resetPrimaryTeam(userID, teamName)
+	for i, update := range updates {
+		r.updateReviewers(prs[i], update.Reviewers, update.Events)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RenameTeam(_ context.Context, teamName, newName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, ok := r.teams[teamName]
+	if !ok {
+		return app.ErrNotFound
+	}
+	if _, exists := r.teams[newName]; exists {
+		return &app.ErrTeamExists{TeamName: newName}
+	}
+
+	delete(r.teams, teamName)
+	r.teams[newName] = settings
+	r.members[newName] = r.members[teamName]
+	delete(r.members, teamName)
+	r.pools[newName] = r.pools[teamName]
+	delete(r.pools, teamName)
+	r.codeOwners[newName] = r.codeOwners[teamName]
+	delete(r.codeOwners, teamName)
+	for _, pools := range r.pools {
+		for i := range pools {
+			if pools[i].TeamName == teamName {
+				pools[i].TeamName = newName
+			}
+		}
+	}
+	for id, user := range r.users {
+		if user.TeamName == teamName {
+			user.TeamName = newName
+			r.users[id] = user
+		}
+	}
+	for _, pr := range r.prs {
+		if pr.TeamName == teamName {
+			pr.TeamName = newName
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteTeam(_ context.Context, teamName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.teams[teamName]; !ok {
+		return app.ErrNotFound
+	}
+	for _, pr := range r.prs {
+		if pr.TeamName == teamName && (pr.Status == domain.StatusOpen || pr.Status == domain.StatusDraft) {
+			return app.ErrTeamHasOpenPRs
+		}
+	}
+
+	members := r.members[teamName]
+	delete(r.teams, teamName)
+	delete(r.members, teamName)
+	delete(r.pools, teamName)
+	delete(r.codeOwners, teamName)
+	// Как и каскадное удаление в БД, убираем пулы других команд, ссылающиеся на удаленную.
+	for name, pools := range r.pools {
+		r.pools[name] = slices.DeleteFunc(pools, func(pool domain.ReviewerPool) bool { return pool.TeamName == teamName })
+	}
+	for userID := range members {
+		r.resetPrimaryTeam(userID, teamName)
+	}
+	for _, pr := range r.prs {
+		if pr.TeamName == teamName {
+			pr.TeamName = ""
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) SetUserActivity(_ context.Context, userID string, isActive bool) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, app.ErrUserNotFound
+	}
+	user.IsActive = isActive
+	r.users[userID] = user
+	user = r.user(userID)
+	return &user, nil
+}
+
+func (r *MemoryRepository) GetUserByID(_ context.Context, userID string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.users[userID]; !ok {
+		return nil, app.ErrUserNotFound
+	}
+	user := r.user(userID)
+	return &user, nil
+}
+
+func (r *MemoryRepository) CountOpenReviews(_ context.Context, userIDs []string) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int, len(userIDs))
+	for _, id := range userIDs {
+		counts[id] = 0
+	}
+	for _, pr := range r.prs {
+		if pr.Status != domain.StatusOpen {
+			continue
+		}
+		for _, reviewerID := range pr.AssignedReviewers {
+			if _, ok := counts[reviewerID]; ok {
+				counts[reviewerID]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (r *MemoryRepository) DeactivateUsers(_ context.Context, userIDs []string, updates []domain.ReviewerUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Сначала проверяем все условия, чтобы при ошибке ничего не изменить.
+	for _, userID := range userIDs {
+		if _, ok := r.users[userID]; !ok {
+			return app.ErrUserNotFound
+		}
+	}
+	prs := make([]*domain.PullRequest, len(updates))
+	for i, update := range updates {
+		pr, err := r.lockedPR(update.PullRequestID, update.Version)
+		if err != nil {
+			return err
+		}
+		prs[i] = pr
+	}
+
+	for _, userID := range userIDs {
+		user := r.users[userID]
+		user.IsActive = false
+		r.users[userID] = user
+	}
+	for i, update := range updates {
+		r.updateReviewers(prs[i], update.Reviewers, update.Events)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateAbsence(_ context.Context, absence domain.Absence) (domain.Absence, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[absence.UserID]; !ok {
+		return domain.Absence{}, app.ErrUserNotFound
+	}
+	r.nextAbsence++
+	absence.ID = r.nextAbsence
+	absence.CreatedAt = time.Now()
+	r.absences[absence.ID] = absence
+	return absence, nil
+}
+
+func (r *MemoryRepository) GetAbsences(_ context.Context, userID string) ([]domain.Absence, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	absences := make([]domain.Absence, 0)
+	for _, absence := range r.absences {
+		if absence.UserID == userID {
+			absences = append(absences, absence)
+		}
+	}
+	sort.Slice(absences, func(i, j int) bool {
+		if !absences[i].StartsAt.Equal(absences[j].StartsAt) {
+			return absences[i].StartsAt.Before(absences[j].StartsAt)
+		}
+		return absences[i].ID < absences[j].ID
+	})
+	return absences, nil
+}
+
+func (r *MemoryRepository) DeleteAbsence(_ context.Context, absenceID int64) (domain.Absence, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	absence, ok := r.absences[absenceID]
+	if !ok {
+		return domain.Absence{}, app.ErrAbsenceNotFound
+	}
+	delete(r.absences, absenceID)
+	return absence, nil
+}
+
+func (r *MemoryRepository) GetAbsence(_ context.Context, absenceID int64) (domain.Absence, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	absence, ok := r.absences[absenceID]
+	if !ok {
+		return domain.Absence{}, app.ErrAbsenceNotFound
+	}
+	return absence, nil
+}
+
+func (r *MemoryRepository) AbsentUsers(_ context.Context, userIDs []string, at time.Time) (map[string]bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	absent := make(map[string]bool)
+	for _, absence := range r.absences {
+		if absence.Covers(at) && slices.Contains(userIDs, absence.UserID) {
+			absent[absence.UserID] = true
+		}
+	}
+	return absent, nil
+}
+
+func (r *MemoryRepository) CreatePullRequest(_ context.Context, pr domain.PullRequest, events ...domain.PREvent) (*domain.PullRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.prs[pr.ID]; ok {
+		return nil, app.ErrPRExists
+	}
+
+	pr.Version = 1
+	pr.AssignedReviewers = append([]string{}, pr.AssignedReviewers...)
+	pr.ReviewStates = make(map[string]domain.ReviewState, len(pr.AssignedReviewers))
+	r.assignedAt[pr.ID] = make(map[string]time.Time, len(pr.AssignedReviewers))
+	for _, reviewerID := range pr.AssignedReviewers {
+		pr.ReviewStates[reviewerID] = domain.ReviewPending
+		r.assignedAt[pr.ID][reviewerID] = pr.CreatedAt
+	}
+	r.prs[pr.ID] = clonePR(&pr)
+	r.appendEvents(events)
+	return &pr, nil
+}
+
+func (r *MemoryRepository) GetPullRequestByID(_ context.Context, prID string) (*domain.PullRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pr, ok := r.prs[prID]
+	if !ok {
+		return nil, app.ErrPRNotFound
+	}
+	return clonePR(pr), nil
+}
+
+func (r *MemoryRepository) MergePullRequest(_ context.Context, prID string, version int, forced bool, events ...domain.PREvent) (*domain.PullRequest, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.prs[prID]
+	if !ok {
+		return nil, false, app.ErrPRNotFound
+	}
+	// Повторный запрос возвращает уже слитый PR без изменений, как и в PgRepository.
+	switch {
+	case pr.Status == domain.StatusMerged:
+		return clonePR(pr), false, nil
+	case pr.Status != domain.StatusOpen:
+		return nil, false, &app.ErrInvalidTransition{From: pr.Status, To: domain.StatusMerged}
+	case pr.Version != version:
+		return nil, false, app.ErrVersionConflict
+	}
+
+	now := time.Now()
+	pr.Status = domain.StatusMerged
+	pr.MergedAt = &now
+	pr.ForceMerged = forced
+	pr.Version++
+	r.appendEvents(events)
+	return clonePR(pr), true, nil
+}
+
+func (r *MemoryRepository) UpdatePullRequestStatus(_ context.Context, prID string, version int, to domain.PRStatus, reviewers []string, events ...domain.PREvent) (*domain.PullRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, err := r.lockedPR(prID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	pr.Status = to
+	pr.ClosedAt = nil
+	if to == domain.StatusClosed {
+		now := time.Now()
+		pr.ClosedAt = &now
+	}
+
+	// Полностью заменяем ревьюеров: их ревью начинаются заново.
+	pr.AssignedReviewers = append([]string{}, reviewers...)
+	pr.ReviewStates = make(map[string]domain.ReviewState, len(reviewers))
+	r.assignedAt[pr.ID] = make(map[string]time.Time, len(reviewers))
+	for _, reviewerID := range reviewers {
+		pr.ReviewStates[reviewerID] = domain.ReviewPending
+		r.assignedAt[pr.ID][reviewerID] = time.Now()
+	}
+	pr.Version++
+	r.appendEvents(events)
+	return clonePR(pr), nil
+}
+
+func (r *MemoryRepository) UpdatePullRequestReviewers(_ context.Context, prID string, version int, reviewers []string, events ...domain.PREvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, err := r.lockedPR(prID, version)
+	if err != nil {
+		return err
+	}
+	r.updateReviewers(pr, reviewers, events)
+	return nil
+}
+
+func (r *MemoryRepository) SetReviewState(_ context.Context, prID string, version int, reviewerID string, state domain.ReviewState, events ...domain.PREvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, err := r.lockedPR(prID, version)
+	if err != nil {
+		return err
+	}
+	if _, ok := pr.ReviewStates[reviewerID]; !ok {
+		return app.ErrReviewerNotAssigned
+	}
+
+	pr.ReviewStates[reviewerID] = state
+	pr.Version++
+	r.appendEvents(events)
+	return nil
+}
+
+func (r *MemoryRepository) ListPullRequests(_ context.Context, filter domain.PRFilter) (domain.PRPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	name := strings.ToLower(filter.NameContains)
+	return r.listPullRequests(filter.Sort, filter.After, filter.Limit, func(pr *domain.PullRequest) bool {
+		_, assigned := pr.ReviewStates[filter.ReviewerID]
+		switch {
+		case filter.AuthorID != "" && pr.AuthorID != filter.AuthorID:
+		case filter.TeamName != "" && pr.TeamName != filter.TeamName:
+		case len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, pr.Status):
+		case filter.ReviewerID != "" && !assigned:
+		case !strings.Contains(strings.ToLower(pr.Name), name):
+		case filter.CreatedFrom != nil && pr.CreatedAt.Before(*filter.CreatedFrom):
+		case filter.CreatedTo != nil && !pr.CreatedAt.Before(*filter.CreatedTo):
+		case (filter.MergedFrom != nil || filter.MergedTo != nil) && pr.MergedAt == nil:
+		case filter.MergedFrom != nil && pr.MergedAt.Before(*filter.MergedFrom):
+		case filter.MergedTo != nil && !pr.MergedAt.Before(*filter.MergedTo):
+		default:
+			return true
+		}
+		return false
+	}), nil
+}
+
+func (r *MemoryRepository) ListReviewerPullRequests(_ context.Context, filter domain.ReviewerPRFilter) (domain.PRPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []domain.PRStatus{domain.StatusOpen}
+	}
+	return r.listPullRequests(filter.Sort, filter.After, filter.Limit, func(pr *domain.PullRequest) bool {
+		state, assigned := pr.ReviewStates[filter.ReviewerID]
+		switch {
+		case !assigned || !slices.Contains(statuses, pr.Status):
+		case filter.PendingOnly && state != domain.ReviewPending:
+		case filter.AuthorID != "" && pr.AuthorID != filter.AuthorID:
+		case filter.CreatedFrom != nil && pr.CreatedAt.Before(*filter.CreatedFrom):
+		case filter.CreatedTo != nil && !pr.CreatedAt.Before(*filter.CreatedTo):
+		default:
+			return true
+		}
+		return false
+	}), nil
+}
+
+// listPullRequests выбирает страницу PR, для которых match истинно, в порядке sort, начиная сразу после after.
+// Вызывающий должен держать r.mu.
+func (r *MemoryRepository) listPullRequests(order domain.PRSort, after *domain.PRCursor, limit int, match func(*domain.PullRequest) bool) domain.PRPage {
+	// precedes сообщает, что позиция a идет в выдаче раньше позиции b.
+	precedes := func(a, b domain.PRCursor) bool {
+		c := a.CreatedAt.Compare(b.CreatedAt)
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if order == domain.SortCreatedAsc {
+			return c < 0
+		}
+		return c > 0
+	}
+	key := func(pr *domain.PullRequest) domain.PRCursor {
+		return domain.PRCursor{CreatedAt: pr.CreatedAt, ID: pr.ID}
+	}
+
+	var matched []*domain.PullRequest
+	for _, pr := range r.prs {
+		if match(pr) && (after == nil || precedes(*after, key(pr))) {
+			matched = append(matched, pr)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return precedes(key(matched[i]), key(matched[j])) })
+
+	// Как и в БД, берем на один PR больше, чтобы узнать, есть ли следующая страница.
+	if limit > 0 && len(matched) > limit+1 {
+		matched = matched[:limit+1]
+	}
+	prs := make([]*domain.PullRequest, len(matched))
+	for i, pr := range matched {
+		prs[i] = clonePR(pr)
+	}
+	return domain.NewPRPage(prs, limit)
+}
+
+func (r *MemoryRepository) GetPullRequestEvents(_ context.Context, prID string) ([]domain.PREvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]domain.PREvent{}, r.events[prID]...), nil
+}
+
+func (r *MemoryRepository) ReviewerStats(_ context.Context, filter domain.StatsFilter) ([]domain.ReviewerStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byUser := make(map[string]*domain.ReviewerStats)
+	userStats := func(userID string) *domain.ReviewerStats {
+		if byUser[userID] == nil {
+			byUser[userID] = &domain.ReviewerStats{UserID: userID}
+		}
+		return byUser[userID]
+	}
+	toMerge := make(map[string][]time.Duration)
+	for prID, pr := range r.prs {
+		if filter.TeamName != "" && pr.TeamName != filter.TeamName {
+			continue
+		}
+		// Открытые ревью и время до слияния считаются по текущим назначениям за период.
+		for reviewerID, at := range r.assignedAt[prID] {
+			if !inPeriod(at, filter) {
+				continue
+			}
+			st := userStats(reviewerID)
+			if pr.Status == domain.StatusOpen {
+				st.OpenReviews++
+			}
+			if pr.MergedAt != nil {
+				toMerge[reviewerID] = append(toMerge[reviewerID], pr.MergedAt.Sub(at))
+			}
+		}
+		// Назначения и замены - по событиям за период, включая назначения, с которых ревьюера потом сняли.
+		for _, e := range r.events[prID] {
+			if e.Type != domain.EventReviewerAssigned && e.Type != domain.EventReviewerReplaced || !inPeriod(e.CreatedAt, filter) {
+				continue
+			}
+			if e.ReviewerID != "" {
+				userStats(e.ReviewerID).Assignments++
+			}
+			if e.Type == domain.EventReviewerReplaced && e.OldReviewerID != "" {
+				userStats(e.OldReviewerID).ReassignedAway++
+			}
+		}
+	}
+
+	stats := make([]domain.ReviewerStats, 0, len(byUser))
+	for userID, st := range byUser {
+		st.MedianTimeToMerge = domain.MedianDuration(toMerge[userID])
+		stats = append(stats, *st)
+	}
+	slices.SortFunc(stats, func(a, b domain.ReviewerStats) int { return strings.Compare(a.UserID, b.UserID) })
+	return stats, nil
+}
+
+func (r *MemoryRepository) TeamStats(_ context.Context, filter domain.StatsFilter) ([]domain.TeamStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// PR отбираются по времени создания; команды без PR за период попадают в выдачу с нулями.
+	stats := []domain.TeamStats{}
+	for teamName := range r.teams {
+		if filter.TeamName != "" && teamName != filter.TeamName {
+			continue
+		}
+		st := domain.TeamStats{TeamName: teamName}
+		var toMerge []time.Duration
+		for _, pr := range r.prs {
+			if pr.TeamName != teamName || !inPeriod(pr.CreatedAt, filter) {
+				continue
+			}
+			switch pr.Status {
+			case domain.StatusOpen:
+				st.OpenPRs++
+			case domain.StatusMerged:
+				st.MergedPRs++
+			}
+			if pr.MergedAt != nil {
+				toMerge = append(toMerge, pr.MergedAt.Sub(pr.CreatedAt))
+			}
+		}
+		if len(toMerge) > 0 {
+			var total time.Duration
+			for _, d := range toMerge {
+				total += d
+			}
+			avg := total / time.Duration(len(toMerge))
+			st.AvgTimeToMerge = &avg
+		}
+		stats = append(stats, st)
+	}
+	slices.SortFunc(stats, func(a, b domain.TeamStats) int { return strings.Compare(a.TeamName, b.TeamName) })
+	return stats, nil
+}
+
+func (r *MemoryRepository) CreateAPIToken(_ context.Context, token domain.APIToken, tokenHash string) (domain.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[token.UserID]; token.UserID != "" && !ok {
+		return domain.APIToken{}, app.ErrUserNotFound
+	}
+	if _, ok := r.tokenHashes[tokenHash]; ok {
+		return domain.APIToken{}, fmt.Errorf("api token hash already exists")
+	}
+	r.nextToken++
+	token.ID = r.nextToken
+	token.CreatedAt = time.Now()
+	r.tokens[token.ID] = token
+	r.tokenHashes[tokenHash] = token.ID
+	return token, nil
+}
+
+func (r *MemoryRepository) GetAPITokenByHash(_ context.Context, tokenHash string) (domain.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.tokenHashes[tokenHash]
+	if !ok {
+		return domain.APIToken{}, app.ErrTokenNotFound
+	}
+	return r.tokens[id], nil
+}
+
+func (r *MemoryRepository) GetAPIToken(_ context.Context, tokenID int64) (domain.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[tokenID]
+	if !ok {
+		return domain.APIToken{}, app.ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (r *MemoryRepository) ListAPITokens(_ context.Context, userID string) ([]domain.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tokens := make([]domain.APIToken, 0)
+	for _, token := range r.tokens {
+		if userID == "" || token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, nil
+}
+
+func (r *MemoryRepository) RevokeAPIToken(_ context.Context, tokenID int64, at time.Time) (domain.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenID]
+	if !ok {
+		return domain.APIToken{}, app.ErrTokenNotFound
+	}
+	if token.RevokedAt == nil {
+		token.RevokedAt = &at
+		r.tokens[tokenID] = token
+	}
+	return token, nil
+}
+
+func (r *MemoryRepository) ReserveIdempotencyKey(_ context.Context, key, requestHash string, staleBefore, expiredBefore time.Time) (*domain.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.idempotency[key]
+	stale := !record.Completed() && record.CreatedAt.Before(staleBefore)
+	if ok && !stale && !record.CreatedAt.Before(expiredBefore) {
+		record.Body = append([]byte(nil), record.Body...)
+		return &record, false, nil
+	}
+	r.idempotency[key] = domain.IdempotencyRecord{Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	return nil, true, nil
+}
+
+func (r *MemoryRepository) SaveIdempotencyResponse(_ context.Context, response domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.idempotency[response.Key]; ok {
+		record.StatusCode = response.StatusCode
+		record.ContentType = response.ContentType
+		record.ETag = response.ETag
+		record.Body = append([]byte(nil), response.Body...)
+		r.idempotency[response.Key] = record
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.idempotency, key)
+	return nil
+}
+
+func (r *MemoryRepository) DeleteExpiredIdempotencyKeys(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key, record := range r.idempotency {
+		if record.CreatedAt.Before(before) {
+			delete(r.idempotency, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// addMember добавляет пользователя в команду, сохраняя его членство в других командах.
+// Атрибуты существующего пользователя не меняются, а основная команда назначается,
+// только если её не было. Вызывается под r.mu.
+func (r *MemoryRepository) addMember(teamName string, member domain.User) {
+	if existing, ok := r.users[member.ID]; ok {
+		member = existing
+	}
+	if member.TeamName == "" {
+		member.TeamName = teamName
+	}
+	member.Teams = nil
+	r.users[member.ID] = member
+	r.members[teamName][member.ID] = struct{}{}
+}
+
+// resetPrimaryTeam назначает основной первую по имени из оставшихся команд пользователя,
+// если он покинул свою основную команду. Вызывается под r.mu.
+func (r *MemoryRepository) resetPrimaryTeam(userID, teamName string) {
+	user, ok := r.users[userID]
+	if !ok || user.TeamName != teamName {
+		return
+	}
+	user.TeamName = ""
+	if teams := r.userTeams(userID); len(teams) > 0 {
+		user.TeamName = teams[0]
+	}
+	r.users[userID] = user
+}
+
+// user возвращает копию пользователя со списком его команд. Вызывается под r.mu.
+func (r *MemoryRepository) user(userID string) domain.User {
+	user := r.users[userID]
+	user.Teams = r.userTeams(userID)
+	return user
+}
+
+// userTeams возвращает команды пользователя в порядке имени. Вызывается под r.mu.
+func (r *MemoryRepository) userTeams(userID string) []string {
+	teams := []string{}
+	for teamName, members := range r.members {
+		if _, ok := members[userID]; ok {
+			teams = append(teams, teamName)
+		}
+	}
+	sort.Strings(teams)
+	return teams
+}
+
+// validatePools проверяет, что пулы ссылаются на существующие команды и пользователей,
+// включая ещё не сохраненных участников создаваемой команды. Вызывается под r.mu.
+func (r *MemoryRepository) validatePools(pools []domain.ReviewerPool, members []domain.User) error {
+	for _, pool := range pools {
+		if _, ok := r.teams[pool.TeamName]; pool.TeamName != "" && !ok {
+			return fmt.Errorf("%w: unknown team or user", app.ErrInvalidReviewerPool)
+		}
+		for _, userID := range pool.UserIDs {
+			_, ok := r.users[userID]
+			if !ok && !slices.ContainsFunc(members, func(member domain.User) bool { return member.ID == userID }) {
+				return fmt.Errorf("%w: unknown team or user", app.ErrInvalidReviewerPool)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCodeOwners проверяет, что правила ссылаются на существующих пользователей,
+// включая ещё не сохраненных участников создаваемой команды. Вызывается под r.mu.
+func (r *MemoryRepository) validateCodeOwners(rules []domain.CodeOwnerRule, members []domain.User) error {
+	for _, rule := range rules {
+		for _, userID := range rule.Owners {
+			_, ok := r.users[userID]
+			if !ok && !slices.ContainsFunc(members, func(member domain.User) bool { return member.ID == userID }) {
+				return fmt.Errorf("%w: unknown user %q", app.ErrInvalidCodeOwner, userID)
+			}
+		}
+	}
+	return nil
+}
+
+// lockedPR возвращает PR для изменения, если его версия совпадает с ожидаемой. Вызывается под r.mu.
+func (r *MemoryRepository) lockedPR(prID string, version int) (*domain.PullRequest, error) {
+	pr, ok := r.prs[prID]
+	if !ok {
+		return nil, app.ErrPRNotFound
+	}
+	if pr.Version != version {
+		return nil, app.ErrVersionConflict
+	}
+	return pr, nil
+}
+
+// updateReviewers заменяет ревьюеров PR. Оставшиеся ревьюеры сохраняют состояние ревью,
+// новые начинают с PENDING. Вызывается под r.mu.
+func (r *MemoryRepository) updateReviewers(pr *domain.PullRequest, reviewers []string, events []domain.PREvent) {
+	states := make(map[string]domain.ReviewState, len(reviewers))
+	assignedAt := make(map[string]time.Time, len(reviewers))
+	for _, reviewerID := range reviewers {
+		state, ok := pr.ReviewStates[reviewerID]
+		if !ok {
+			state = domain.ReviewPending
+		}
+		at, ok := r.assignedAt[pr.ID][reviewerID]
+		if !ok {
+			at = time.Now()
+		}
+		states[reviewerID] = state
+		assignedAt[reviewerID] = at
+	}
+	pr.AssignedReviewers = append([]string{}, reviewers...)
+	pr.ReviewStates = states
+	r.assignedAt[pr.ID] = assignedAt
+	pr.Version++
+	r.appendEvents(events)
+}
+
+// appendEvents добавляет события в журнал, выдавая им возрастающие ID. Вызывается под r.mu.
+func (r *MemoryRepository) appendEvents(events []domain.PREvent) {
+	for _, e := range events {
+		r.nextEventID++
+		e.ID = r.nextEventID
+		r.events[e.PullRequestID] = append(r.events[e.PullRequestID], e)
+	}
+}
+
+// inPeriod сообщает, что момент at попадает в период filter.
+func inPeriod(at time.Time, filter domain.StatsFilter) bool {
+	return (filter.From == nil || !at.Before(*filter.From)) && (filter.To == nil || at.Before(*filter.To))
+}
+
+// clonePools копирует пулы вместе со списками пользователей, убирая повторы, как первичный ключ в БД.
+func clonePools(pools []domain.ReviewerPool) []domain.ReviewerPool {
+	clone := make([]domain.ReviewerPool, len(pools))
+	for i, pool := range pools {
+		clone[i] = pool
+		clone[i].UserIDs = nil
+		if len(pool.UserIDs) > 0 {
+			userIDs := slices.Clone(pool.UserIDs)
+			slices.Sort(userIDs)
+			clone[i].UserIDs = slices.Compact(userIDs)
+		}
+	}
+	return clone
+}
+
+// cloneCodeOwners копирует правила вместе со списками владельцев, убирая повторы, как первичный ключ в БД.
+func cloneCodeOwners(rules []domain.CodeOwnerRule) []domain.CodeOwnerRule {
+	clone := make([]domain.CodeOwnerRule, len(rules))
+	for i, rule := range rules {
+		owners := slices.Clone(rule.Owners)
+		slices.Sort(owners)
+		clone[i] = domain.CodeOwnerRule{Pattern: rule.Pattern, Owners: append([]string{}, slices.Compact(owners)...)}
+	}
+	return clone
+}
+
+// clonePR копирует PR вместе со срезами и map, чтобы вызывающий код не менял хранилище.
+// Ревьюеры упорядочены по ID, как в PgRepository.
+func clonePR(pr *domain.PullRequest) *domain.PullRequest {
+	clone := *pr
+	clone.ChangedFiles = append([]string{}, pr.ChangedFiles...)
+	clone.AssignedReviewers = append([]string{}, pr.AssignedReviewers...)
+	slices.Sort(clone.AssignedReviewers)
+	clone.ReviewStates = make(map[string]domain.ReviewState, len(pr.ReviewStates))
+	for id, state := range pr.ReviewStates {
+		clone.ReviewStates[id] = state
+	}
+	return &clone
+}
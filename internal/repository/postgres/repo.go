@@ -3,6 +3,12 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -12,7 +18,10 @@ import (
 	"github.com/wsppppp/manage-pull-request/internal/repository"
 )
 
-const uniqueViolationCode = "23505"
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+)
 
 type PgRepository struct {
 	db *pgxpool.Pool
@@ -30,7 +39,10 @@ func (r *PgRepository) CreateTeam(ctx context.Context, team domain.Team) (domain
 	defer tx.Rollback(ctx)
 
 	// Создаем запись о команде.
-	_, err = tx.Exec(ctx, `INSERT INTO teams (team_name) VALUES ($1)`, team.Name)
+	_, err = tx.Exec(ctx,
+		`INSERT INTO teams (team_name, reviewer_strategy, min_reviewers, max_reviewers, merge_policy, required_approvals)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		team.Name, team.ReviewerStrategy, team.MinReviewers, team.MaxReviewers, team.MergePolicy, team.RequiredApprovals)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
@@ -39,15 +51,18 @@ func (r *PgRepository) CreateTeam(ctx context.Context, team domain.Team) (domain
 		return domain.Team{}, err
 	}
 
-	// Добавляем или обновляем участников команды.
+	// Добавляем участников; пользователи из других команд остаются и в них.
 	for _, member := range team.Members {
-		_, err = tx.Exec(ctx, `INSERT INTO users (user_id, username, is_active, team_name) VALUES ($1, $2, $3, $4)
-                           ON CONFLICT (user_id) DO UPDATE SET username = $2, is_active = $3, team_name = $4`,
-			member.ID, member.Username, member.IsActive, team.Name)
-		if err != nil {
+		if err := addMember(ctx, tx, team.Name, member); err != nil {
 			return domain.Team{}, err
 		}
 	}
+	if err := replaceReviewerPools(ctx, tx, team.Name, team.ReviewerPools); err != nil {
+		return domain.Team{}, err
+	}
+	if err := replaceCodeOwners(ctx, tx, team.Name, team.CodeOwners); err != nil {
+		return domain.Team{}, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return domain.Team{}, err
@@ -58,8 +73,24 @@ func (r *PgRepository) CreateTeam(ctx context.Context, team domain.Team) (domain
 func (r *PgRepository) GetTeamByName(ctx context.Context, teamName string) (domain.Team, error) {
 	team := domain.Team{Name: teamName}
 
-	// Находим всех участников указанной команды.
-	rows, err := r.db.Query(ctx, `SELECT user_id, username, is_active FROM users WHERE team_name = $1`, teamName)
+	// Получаем настройки команды, заодно проверяя её существование.
+	err := r.db.QueryRow(ctx,
+		`SELECT reviewer_strategy, min_reviewers, max_reviewers, merge_policy, required_approvals
+		 FROM teams WHERE team_name = $1`, teamName,
+	).Scan(&team.ReviewerStrategy, &team.MinReviewers, &team.MaxReviewers, &team.MergePolicy, &team.RequiredApprovals)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Team{}, app.ErrNotFound
+		}
+		return domain.Team{}, err
+	}
+
+	// Находим всех участников указанной команды вместе с их остальными командами.
+	rows, err := r.db.Query(ctx,
+		`SELECT u.user_id, u.username, u.is_active, u.review_weight, COALESCE(u.team_name, ''),
+		        ARRAY(SELECT team_name FROM team_members WHERE user_id = u.user_id ORDER BY team_name)
+		 FROM team_members m JOIN users u ON u.user_id = m.user_id
+		 WHERE m.team_name = $1`, teamName)
 	if err != nil {
 		return domain.Team{}, err
 	}
@@ -67,26 +98,245 @@ func (r *PgRepository) GetTeamByName(ctx context.Context, teamName string) (doma
 
 	for rows.Next() {
 		var user domain.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.IsActive); err != nil {
+		if err := rows.Scan(&user.ID, &user.Username, &user.IsActive, &user.ReviewWeight, &user.TeamName, &user.Teams); err != nil {
 			return domain.Team{}, err
 		}
-		user.TeamName = teamName
 		team.Members = append(team.Members, user)
 	}
+	if rows.Err() != nil {
+		return domain.Team{}, rows.Err()
+	}
+
+	// Пулы ревьюеров в порядке их задания.
+	poolRows, err := r.db.Query(ctx,
+		`SELECT p.mode, COALESCE(p.source_team, ''),
+		        ARRAY(SELECT user_id FROM reviewer_pool_users WHERE pool_id = p.id ORDER BY user_id)
+		 FROM reviewer_pools p WHERE p.team_name = $1 ORDER BY p.id`, teamName)
+	if err != nil {
+		return domain.Team{}, err
+	}
+	defer poolRows.Close()
+
+	team.ReviewerPools = []domain.ReviewerPool{}
+	for poolRows.Next() {
+		var pool domain.ReviewerPool
+		if err := poolRows.Scan(&pool.Mode, &pool.TeamName, &pool.UserIDs); err != nil {
+			return domain.Team{}, err
+		}
+		team.ReviewerPools = append(team.ReviewerPools, pool)
+	}
+	if poolRows.Err() != nil {
+		return domain.Team{}, poolRows.Err()
+	}
+
+	// Правила владения кодом в порядке их задания.
+	ruleRows, err := r.db.Query(ctx,
+		`SELECT c.pattern, ARRAY(SELECT user_id FROM code_owner_rule_users WHERE rule_id = c.id ORDER BY user_id)
+		 FROM code_owner_rules c WHERE c.team_name = $1 ORDER BY c.id`, teamName)
+	if err != nil {
+		return domain.Team{}, err
+	}
+	defer ruleRows.Close()
 
-	// Если не нашли участников, проверяем, существует ли хотя бы сама команда.
-	if len(team.Members) == 0 {
+	team.CodeOwners = []domain.CodeOwnerRule{}
+	for ruleRows.Next() {
+		var rule domain.CodeOwnerRule
+		if err := ruleRows.Scan(&rule.Pattern, &rule.Owners); err != nil {
+			return domain.Team{}, err
+		}
+		team.CodeOwners = append(team.CodeOwners, rule)
+	}
+	if ruleRows.Err() != nil {
+		return domain.Team{}, ruleRows.Err()
+	}
+
+	return team, nil
+}
+
+func (r *PgRepository) UpdateTeamSettings(ctx context.Context, teamName string, settings domain.TeamSettings) (domain.TeamSettings, error) {
+	// Сохраняем настройки и возвращаем то, что записалось в базу.
+	var updated domain.TeamSettings
+	err := r.db.QueryRow(ctx,
+		`UPDATE teams SET reviewer_strategy = $1, min_reviewers = $2, max_reviewers = $3,
+		                  merge_policy = $4, required_approvals = $5
+		 WHERE team_name = $6
+		 RETURNING reviewer_strategy, min_reviewers, max_reviewers, merge_policy, required_approvals`,
+		settings.ReviewerStrategy, settings.MinReviewers, settings.MaxReviewers,
+		settings.MergePolicy, settings.RequiredApprovals, teamName,
+	).Scan(&updated.ReviewerStrategy, &updated.MinReviewers, &updated.MaxReviewers,
+		&updated.MergePolicy, &updated.RequiredApprovals)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.TeamSettings{}, app.ErrNotFound
+		}
+		return domain.TeamSettings{}, err
+	}
+	return updated, nil
+}
+
+func (r *PgRepository) SetReviewerPools(ctx context.Context, teamName string, pools []domain.ReviewerPool) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем команду, чтобы параллельные замены пулов не перемешались.
+	var locked string
+	err = tx.QueryRow(ctx, `SELECT team_name FROM teams WHERE team_name = $1 FOR UPDATE`, teamName).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return app.ErrNotFound
+		}
+		return err
+	}
+
+	if err := replaceReviewerPools(ctx, tx, teamName, pools); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PgRepository) SetCodeOwners(ctx context.Context, teamName string, rules []domain.CodeOwnerRule) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем команду, чтобы параллельные замены правил не перемешались.
+	var locked string
+	err = tx.QueryRow(ctx, `SELECT team_name FROM teams WHERE team_name = $1 FOR UPDATE`, teamName).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return app.ErrNotFound
+		}
+		return err
+	}
+
+	if err := replaceCodeOwners(ctx, tx, teamName, rules); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PgRepository) AddTeamMember(ctx context.Context, teamName string, member domain.User) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := addMember(ctx, tx, teamName, member); err != nil {
+		// Отсутствие команды нарушает внешний ключ.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+			return app.ErrNotFound
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PgRepository) RemoveTeamMember(ctx context.Context, teamName, userID string, updates []domain.ReviewerUpdate) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM team_members WHERE team_name = $1 AND user_id = $2`, teamName, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		// Ничего не удалили: либо нет команды, либо пользователь в ней не состоит.
 		var exists bool
-		err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)`, teamName).Scan(&exists)
+		err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)`, teamName).Scan(&exists)
 		if err != nil {
-			return domain.Team{}, err
+			return err
 		}
 		if !exists {
-			return domain.Team{}, app.ErrNotFound
+			return app.ErrNotFound
 		}
+		return app.ErrNotTeamMember
 	}
 
-	return team, nil
+	// Если команда была основной, основной становится первая из оставшихся.
+	_, err = tx.Exec(ctx,
+		`UPDATE users SET team_name = (SELECT MIN(team_name) FROM team_members WHERE user_id = $1)
+		 WHERE user_id = $1 AND team_name = $2`,
+		userID, teamName)
+	if err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		if err := r.updateReviewers(ctx, tx, update.PullRequestID, update.Version, update.Reviewers, update.Events); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PgRepository) RenameTeam(ctx context.Context, teamName, newName string) error {
+	// Участники и PR переезжают вслед за командой через ON UPDATE CASCADE.
+	tag, err := r.db.Exec(ctx, `UPDATE teams SET team_name = $1 WHERE team_name = $2`, newName, teamName)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return &app.ErrTeamExists{TeamName: newName}
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return app.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PgRepository) DeleteTeam(ctx context.Context, teamName string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем команду, чтобы в неё не добавили участников во время проверки.
+	var locked string
+	err = tx.QueryRow(ctx, `SELECT team_name FROM teams WHERE team_name = $1 FOR UPDATE`, teamName).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return app.ErrNotFound
+		}
+		return err
+	}
+
+	// Без команды незавершенным PR не из кого выбирать ревьюеров, поэтому они блокируют удаление.
+	var hasOpen bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM pull_requests WHERE team_name = $1 AND status IN ('OPEN', 'DRAFT'))`,
+		teamName).Scan(&hasOpen)
+	if err != nil {
+		return err
+	}
+	if hasOpen {
+		return app.ErrTeamHasOpenPRs
+	}
+
+	// Членство удаляется каскадно, ссылки пользователей и PR на команду обнуляются.
+	if _, err := tx.Exec(ctx, `DELETE FROM teams WHERE team_name = $1`, teamName); err != nil {
+		return err
+	}
+
+	// Участникам, для которых команда была основной, назначаем основной первую из оставшихся.
+	_, err = tx.Exec(ctx,
+		`UPDATE users u SET team_name = (SELECT MIN(m.team_name) FROM team_members m WHERE m.user_id = u.user_id)
+		 WHERE u.team_name IS NULL AND EXISTS(SELECT 1 FROM team_members m WHERE m.user_id = u.user_id)`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *PgRepository) SetUserActivity(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
@@ -94,9 +344,11 @@ func (r *PgRepository) SetUserActivity(ctx context.Context, userID string, isAct
 
 	// Обновляем статус пользователя и возвращаем обновленную запись.
 	err := r.db.QueryRow(ctx,
-		`UPDATE users SET is_active = $1 WHERE user_id = $2 RETURNING user_id, username, is_active, team_name`,
+		`UPDATE users SET is_active = $1 WHERE user_id = $2
+		 RETURNING user_id, username, is_active, COALESCE(team_name, ''), review_weight,
+		           ARRAY(SELECT team_name FROM team_members WHERE user_id = $2 ORDER BY team_name)`,
 		isActive, userID,
-	).Scan(&user.ID, &user.Username, &user.IsActive, &user.TeamName)
+	).Scan(&user.ID, &user.Username, &user.IsActive, &user.TeamName, &user.ReviewWeight, &user.Teams)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -110,11 +362,13 @@ func (r *PgRepository) SetUserActivity(ctx context.Context, userID string, isAct
 func (r *PgRepository) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
 	user := &domain.User{}
 
-	// Находим пользователя по его ID.
+	// Находим пользователя по его ID вместе со всеми его командами.
 	err := r.db.QueryRow(ctx,
-		`SELECT user_id, username, is_active, team_name FROM users WHERE user_id = $1`,
+		`SELECT user_id, username, is_active, COALESCE(team_name, ''), review_weight,
+		        ARRAY(SELECT team_name FROM team_members WHERE user_id = $1 ORDER BY team_name)
+		 FROM users WHERE user_id = $1`,
 		userID,
-	).Scan(&user.ID, &user.Username, &user.IsActive, &user.TeamName)
+	).Scan(&user.ID, &user.Username, &user.IsActive, &user.TeamName, &user.ReviewWeight, &user.Teams)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -125,18 +379,164 @@ func (r *PgRepository) GetUserByID(ctx context.Context, userID string) (*domain.
 	return user, nil
 }
 
-func (r *PgRepository) CreatePullRequest(ctx context.Context, pr domain.PullRequest) (*domain.PullRequest, error) {
+func (r *PgRepository) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(userIDs))
+	for _, id := range userIDs {
+		counts[id] = 0
+	}
+
+	// Считаем открытые PR, назначенные каждому из пользователей, одним запросом.
+	rows, err := r.db.Query(ctx,
+		`SELECT r.reviewer_id, COUNT(*)
+		 FROM pr_reviewers r
+		 JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		 WHERE r.reviewer_id = ANY($1) AND pr.status = 'OPEN'
+		 GROUP BY r.reviewer_id`,
+		userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+		counts[userID] = count
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return counts, nil
+}
+
+func (r *PgRepository) DeactivateUsers(ctx context.Context, userIDs []string, updates []domain.ReviewerUpdate) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET is_active = FALSE WHERE user_id = ANY($1)`, userIDs)
+	if err != nil {
+		return err
+	}
+	// Повторяющийся идентификатор обновляет строку один раз.
+	distinct := len(slices.Compact(slices.Sorted(slices.Values(userIDs))))
+	if tag.RowsAffected() != int64(distinct) {
+		return app.ErrUserNotFound
+	}
+
+	for _, update := range updates {
+		if err := r.updateReviewers(ctx, tx, update.PullRequestID, update.Version, update.Reviewers, update.Events); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PgRepository) CreateAbsence(ctx context.Context, absence domain.Absence) (domain.Absence, error) {
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO user_absences (user_id, starts_at, ends_at, reason) VALUES ($1, $2, $3, $4)
+		 RETURNING absence_id, created_at`,
+		absence.UserID, absence.StartsAt, absence.EndsAt, absence.Reason,
+	).Scan(&absence.ID, &absence.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+			return domain.Absence{}, app.ErrUserNotFound
+		}
+		return domain.Absence{}, err
+	}
+	return absence, nil
+}
+
+func (r *PgRepository) GetAbsences(ctx context.Context, userID string) ([]domain.Absence, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT absence_id, user_id, starts_at, ends_at, reason, created_at
+		 FROM user_absences WHERE user_id = $1 ORDER BY starts_at, absence_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	absences := make([]domain.Absence, 0)
+	for rows.Next() {
+		var a domain.Absence
+		if err := rows.Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		absences = append(absences, a)
+	}
+	return absences, rows.Err()
+}
+
+func (r *PgRepository) DeleteAbsence(ctx context.Context, absenceID int64) (domain.Absence, error) {
+	var a domain.Absence
+	err := r.db.QueryRow(ctx,
+		`DELETE FROM user_absences WHERE absence_id = $1
+		 RETURNING absence_id, user_id, starts_at, ends_at, reason, created_at`, absenceID,
+	).Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Absence{}, app.ErrAbsenceNotFound
+		}
+		return domain.Absence{}, err
+	}
+	return a, nil
+}
+
+func (r *PgRepository) GetAbsence(ctx context.Context, absenceID int64) (domain.Absence, error) {
+	var a domain.Absence
+	err := r.db.QueryRow(ctx,
+		`SELECT absence_id, user_id, starts_at, ends_at, reason, created_at
+		 FROM user_absences WHERE absence_id = $1`, absenceID,
+	).Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Absence{}, app.ErrAbsenceNotFound
+		}
+		return domain.Absence{}, err
+	}
+	return a, nil
+}
+
+func (r *PgRepository) AbsentUsers(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT DISTINCT user_id FROM user_absences
+		 WHERE user_id = ANY($1) AND starts_at <= $2 AND ends_at > $2`, userIDs, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	absent := make(map[string]bool)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		absent[userID] = true
+	}
+	return absent, rows.Err()
+}
+
+func (r *PgRepository) CreatePullRequest(ctx context.Context, pr domain.PullRequest, events ...domain.PREvent) (*domain.PullRequest, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
-	// Создаем основную запись о Pull Request.
+	// Создаем основную запись о Pull Request в первой версии.
+	pr.Version = 1
 	_, err = tx.Exec(ctx,
-		`INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-         VALUES ($1, $2, $3, $4, $5)`,
-		pr.ID, pr.Name, pr.AuthorID, pr.Status, pr.CreatedAt,
+		`INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, team_name, status, changed_files,
+                                    required_reviewers, created_at)
+         VALUES ($1, $2, $3, NULLIF($4, ''), $5, COALESCE($6::text[], '{}'), $7, $8)`,
+		pr.ID, pr.Name, pr.AuthorID, pr.TeamName, pr.Status, pr.ChangedFiles, pr.RequiredReviewers, pr.CreatedAt,
 	)
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -146,14 +546,21 @@ func (r *PgRepository) CreatePullRequest(ctx context.Context, pr domain.PullRequ
 		return nil, err
 	}
 
-	// Привязываем ревьюеров к созданному PR.
+	// Привязываем ревьюеров к созданному PR, их ревью начинается в состоянии PENDING.
+	pr.ReviewStates = make(map[string]domain.ReviewState, len(pr.AssignedReviewers))
 	for _, reviewerID := range pr.AssignedReviewers {
-		_, err := tx.Exec(ctx, `INSERT INTO pr_reviewers (pr_id, reviewer_id) VALUES ($1, $2)`, pr.ID, reviewerID)
+		pr.ReviewStates[reviewerID] = domain.ReviewPending
+		_, err := tx.Exec(ctx, `INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at) VALUES ($1, $2, $3)`,
+			pr.ID, reviewerID, pr.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if err := insertEvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
@@ -165,9 +572,11 @@ func (r *PgRepository) GetPullRequestByID(ctx context.Context, prID string) (*do
 
 	// Получаем основную информацию о PR.
 	err := r.db.QueryRow(ctx,
-		`SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+		`SELECT pull_request_id, pull_request_name, author_id, COALESCE(team_name, ''), status, changed_files,
+		        required_reviewers, created_at, merged_at, closed_at, force_merged, version
 		 FROM pull_requests WHERE pull_request_id = $1`,
-		prID).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &pr.MergedAt)
+		prID).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.TeamName, &pr.Status, &pr.ChangedFiles, &pr.RequiredReviewers,
+		&pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt, &pr.ForceMerged, &pr.Version)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, app.ErrPRNotFound
@@ -175,92 +584,705 @@ func (r *PgRepository) GetPullRequestByID(ctx context.Context, prID string) (*do
 		return nil, err
 	}
 
-	// Получаем список ID ревьюеров для этого PR.
-	rows, err := r.db.Query(ctx, `SELECT reviewer_id FROM pr_reviewers WHERE pr_id = $1`, prID)
+	// Получаем ревьюеров этого PR вместе с состоянием их ревью в том же порядке, что и в списках PR.
+	rows, err := r.db.Query(ctx, `SELECT reviewer_id, review_state FROM pr_reviewers WHERE pr_id = $1 ORDER BY reviewer_id`, prID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	pr.AssignedReviewers = []string{}
+	pr.ReviewStates = make(map[string]domain.ReviewState)
 	for rows.Next() {
 		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
+		var state domain.ReviewState
+		if err := rows.Scan(&reviewerID, &state); err != nil {
 			return nil, err
 		}
 		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		pr.ReviewStates[reviewerID] = state
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
 	}
 	return pr, nil
 }
 
-func (r *PgRepository) MergePullRequest(ctx context.Context, prID string) (*domain.PullRequest, error) {
-	// Обновляем статус PR и время слияния.
-	_, err := r.db.Exec(ctx,
-		`UPDATE pull_requests SET status = $1, merged_at = NOW() WHERE pull_request_id = $2`,
-		domain.StatusMerged, prID)
+func (r *PgRepository) MergePullRequest(ctx context.Context, prID string, version int, forced bool, events ...domain.PREvent) (*domain.PullRequest, bool, error) {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return r.GetPullRequestByID(ctx, prID)
+	defer tx.Rollback(ctx)
+
+	// Сливаем PR одним условным UPDATE: параллельный запрос не пройдет условие по статусу и версии,
+	// поэтому merged_at выставляется ровно один раз.
+	var mergedID string
+	err = tx.QueryRow(ctx,
+		`UPDATE pull_requests SET status = $1, merged_at = NOW(), force_merged = $2, version = version + 1
+		 WHERE pull_request_id = $3 AND status = $4 AND version = $5
+		 RETURNING pull_request_id`,
+		domain.StatusMerged, forced, prID, domain.StatusOpen, version).Scan(&mergedID)
+	merged := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+
+	// События пишем только при фактическом слиянии, повторный запрос журнал не меняет.
+	if merged {
+		if err := insertEvents(ctx, tx, events); err != nil {
+			return nil, false, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+
+	pr, err := r.GetPullRequestByID(ctx, prID)
+	if err != nil {
+		return nil, false, err
+	}
+	// Ничего не обновили: PR уже слит (повторный запрос), изменен параллельно или находится в другом статусе.
+	if !merged {
+		switch pr.Status {
+		case domain.StatusMerged:
+		case domain.StatusOpen:
+			return nil, false, app.ErrVersionConflict
+		default:
+			return nil, false, &app.ErrInvalidTransition{From: pr.Status, To: domain.StatusMerged}
+		}
+	}
+	return pr, merged, nil
 }
 
-func (r *PgRepository) UpdatePullRequestReviewers(ctx context.Context, prID string, reviewers []string) error {
+func (r *PgRepository) UpdatePullRequestStatus(ctx context.Context, prID string, version int, to domain.PRStatus, reviewers []string, events ...domain.PREvent) (*domain.PullRequest, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
-	// Удаляем всех текущих ревьюеров.
-	if _, err := tx.Exec(ctx, `DELETE FROM pr_reviewers WHERE pr_id = $1`, prID); err != nil {
-		return err
+	// Меняем статус, только если PR не изменился с момента чтения.
+	tag, err := tx.Exec(ctx,
+		`UPDATE pull_requests
+		 SET status = $1, closed_at = CASE WHEN $1 = 'CLOSED'::pr_status THEN NOW() END, version = version + 1
+		 WHERE pull_request_id = $2 AND version = $3`,
+		to, prID, version)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, r.versionError(ctx, prID)
 	}
 
-	// Вставляем новый список ревьюеров.
+	// Полностью заменяем ревьюеров: их ревью начинаются заново.
+	if _, err := tx.Exec(ctx, `DELETE FROM pr_reviewers WHERE pr_id = $1`, prID); err != nil {
+		return nil, err
+	}
 	for _, reviewerID := range reviewers {
 		_, err := tx.Exec(ctx, `INSERT INTO pr_reviewers (pr_id, reviewer_id) VALUES ($1, $2)`, prID, reviewerID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
+	if err := insertEvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.GetPullRequestByID(ctx, prID)
+}
+
+func (r *PgRepository) UpdatePullRequestReviewers(ctx context.Context, prID string, version int, reviewers []string, events ...domain.PREvent) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.updateReviewers(ctx, tx, prID, version, reviewers, events); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PgRepository) SetReviewState(ctx context.Context, prID string, version int, reviewerID string, state domain.ReviewState, events ...domain.PREvent) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.bumpVersion(ctx, tx, prID, version); err != nil {
+		return err
+	}
+
+	// Обновляем решение ревьюера и время его принятия.
+	tag, err := tx.Exec(ctx,
+		`UPDATE pr_reviewers SET review_state = $1, reviewed_at = NOW() WHERE pr_id = $2 AND reviewer_id = $3`,
+		state, prID, reviewerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return app.ErrReviewerNotAssigned
+	}
+
+	if err := insertEvents(ctx, tx, events); err != nil {
+		return err
+	}
 	return tx.Commit(ctx)
 }
 
-func (r *PgRepository) GetOpenPullRequestsByReviewer(ctx context.Context, userID string) ([]*domain.PullRequest, error) {
-	// Находим ID всех открытых PR, назначенных этому пользователю.
+func (r *PgRepository) GetPullRequestEvents(ctx context.Context, prID string) ([]domain.PREvent, error) {
+	// Читаем журнал в порядке записи.
 	rows, err := r.db.Query(ctx,
-		`SELECT pr.pull_request_id
-		 FROM pull_requests pr
-		 JOIN pr_reviewers r ON pr.pull_request_id = r.pr_id
-		 WHERE r.reviewer_id = $1 AND pr.status = 'OPEN'`,
-		userID)
+		`SELECT event_id, pr_id, event_type, COALESCE(actor_id, ''), COALESCE(reviewer_id, ''),
+		        COALESCE(old_reviewer_id, ''), COALESCE(details, ''), created_at
+		 FROM pr_events WHERE pr_id = $1 ORDER BY event_id`,
+		prID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var prIDs []string
+	events := []domain.PREvent{}
 	for rows.Next() {
-		var prID string
-		if err := rows.Scan(&prID); err != nil {
+		var e domain.PREvent
+		if err := rows.Scan(&e.ID, &e.PullRequestID, &e.Type, &e.ActorID, &e.ReviewerID,
+			&e.OldReviewerID, &e.Details, &e.CreatedAt); err != nil {
 			return nil, err
 		}
-		prIDs = append(prIDs, prID)
+		events = append(events, e)
 	}
 	if rows.Err() != nil {
 		return nil, rows.Err()
 	}
+	return events, nil
+}
 
-	// Для каждого найденного ID получаем полную информацию о PR.
-	pullRequests := make([]*domain.PullRequest, 0, len(prIDs))
-	for _, id := range prIDs {
-		pr, err := r.GetPullRequestByID(ctx, id)
+func (r *PgRepository) ReviewerStats(ctx context.Context, filter domain.StatsFilter) ([]domain.ReviewerStats, error) {
+	// Назначения и замены считаются по событиям за период, поэтому учитываются и назначения,
+	// с которых ревьюера потом сняли. Открытые ревью и время до слияния - по текущим назначениям за период.
+	rows, err := r.db.Query(ctx,
+		`WITH events AS (
+		     SELECT e.event_type, e.reviewer_id, e.old_reviewer_id
+		     FROM pr_events e
+		     JOIN pull_requests pr ON pr.pull_request_id = e.pr_id
+		     WHERE e.event_type IN ('reviewer_assigned', 'reviewer_replaced')
+		       AND ($1 = '' OR pr.team_name = $1)
+		       AND ($2::timestamptz IS NULL OR e.created_at >= $2)
+		       AND ($3::timestamptz IS NULL OR e.created_at < $3)
+		 ), assignments AS (
+		     SELECT reviewer_id, COUNT(*) AS assignments
+		     FROM events
+		     WHERE reviewer_id IS NOT NULL
+		     GROUP BY reviewer_id
+		 ), reassigned AS (
+		     SELECT old_reviewer_id AS reviewer_id, COUNT(*) AS reassigned_away
+		     FROM events
+		     WHERE event_type = 'reviewer_replaced' AND old_reviewer_id IS NOT NULL
+		     GROUP BY old_reviewer_id
+		 ), held AS (
+		     SELECT r.reviewer_id,
+		            COUNT(*) FILTER (WHERE pr.status = 'OPEN') AS open_reviews,
+		            percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - r.assigned_at)::float8)
+		                FILTER (WHERE pr.merged_at IS NOT NULL) AS median_to_merge
+		     FROM pr_reviewers r
+		     JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		     WHERE ($1 = '' OR pr.team_name = $1)
+		       AND ($2::timestamptz IS NULL OR r.assigned_at >= $2)
+		       AND ($3::timestamptz IS NULL OR r.assigned_at < $3)
+		     GROUP BY r.reviewer_id
+		 )
+		 SELECT reviewer_id, COALESCE(a.assignments, 0), COALESCE(c.open_reviews, 0),
+		        COALESCE(re.reassigned_away, 0), c.median_to_merge
+		 FROM assignments a
+		 FULL JOIN held c USING (reviewer_id)
+		 FULL JOIN reassigned re USING (reviewer_id)
+		 ORDER BY reviewer_id`,
+		filter.TeamName, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []domain.ReviewerStats{}
+	for rows.Next() {
+		var st domain.ReviewerStats
+		var median *float64
+		if err := rows.Scan(&st.UserID, &st.Assignments, &st.OpenReviews, &st.ReassignedAway, &median); err != nil {
+			return nil, err
+		}
+		st.MedianTimeToMerge = secondsDuration(median)
+		stats = append(stats, st)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return stats, nil
+}
+
+func (r *PgRepository) TeamStats(ctx context.Context, filter domain.StatsFilter) ([]domain.TeamStats, error) {
+	// PR отбираются по времени создания; команды без PR за период попадают в выдачу с нулями.
+	rows, err := r.db.Query(ctx,
+		`SELECT t.team_name,
+		        COUNT(pr.pull_request_id) FILTER (WHERE pr.status = 'OPEN'),
+		        COUNT(pr.pull_request_id) FILTER (WHERE pr.status = 'MERGED'),
+		        AVG(EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)::float8) FILTER (WHERE pr.merged_at IS NOT NULL)
+		 FROM teams t
+		 LEFT JOIN pull_requests pr ON pr.team_name = t.team_name
+		      AND ($2::timestamptz IS NULL OR pr.created_at >= $2)
+		      AND ($3::timestamptz IS NULL OR pr.created_at < $3)
+		 WHERE $1 = '' OR t.team_name = $1
+		 GROUP BY t.team_name
+		 ORDER BY t.team_name`,
+		filter.TeamName, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []domain.TeamStats{}
+	for rows.Next() {
+		var st domain.TeamStats
+		var avg *float64
+		if err := rows.Scan(&st.TeamName, &st.OpenPRs, &st.MergedPRs, &avg); err != nil {
+			return nil, err
+		}
+		st.AvgTimeToMerge = secondsDuration(avg)
+		stats = append(stats, st)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return stats, nil
+}
+
+// addMember добавляет пользователя в команду внутри транзакции. Атрибуты существующего пользователя
+// (имя, активность, вес) не меняются, а его основная команда назначается, только если её не было.
+func addMember(ctx context.Context, tx pgx.Tx, teamName string, member domain.User) error {
+	_, err := tx.Exec(ctx, `INSERT INTO users (user_id, username, is_active, team_name, review_weight) VALUES ($1, $2, $3, $4, $5)
+                           ON CONFLICT (user_id) DO UPDATE SET team_name = COALESCE(users.team_name, $4)`,
+		member.ID, member.Username, member.IsActive, teamName, member.ReviewWeight)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO team_members (team_name, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		teamName, member.ID)
+	return err
+}
+
+// replaceReviewerPools заменяет пулы ревьюеров команды внутри транзакции.
+// Ссылка на несуществующую команду или пользователя возвращает app.ErrInvalidReviewerPool.
+func replaceReviewerPools(ctx context.Context, tx pgx.Tx, teamName string, pools []domain.ReviewerPool) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM reviewer_pools WHERE team_name = $1`, teamName); err != nil {
+		return err
+	}
+	for _, pool := range pools {
+		var poolID int64
+		err := tx.QueryRow(ctx,
+			`INSERT INTO reviewer_pools (team_name, mode, source_team) VALUES ($1, $2, NULLIF($3, '')) RETURNING id`,
+			teamName, pool.Mode, pool.TeamName).Scan(&poolID)
+		if err != nil {
+			return reviewerPoolError(err)
+		}
+		for _, userID := range pool.UserIDs {
+			_, err := tx.Exec(ctx,
+				`INSERT INTO reviewer_pool_users (pool_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+				poolID, userID)
+			if err != nil {
+				return reviewerPoolError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// reviewerPoolError заменяет нарушение внешнего ключа пула на app.ErrInvalidReviewerPool.
+func reviewerPoolError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+		return fmt.Errorf("%w: unknown team or user", app.ErrInvalidReviewerPool)
+	}
+	return err
+}
+
+// replaceCodeOwners заменяет правила владения кодом команды внутри транзакции.
+// Ссылка на несуществующего пользователя возвращает app.ErrInvalidCodeOwner.
+func replaceCodeOwners(ctx context.Context, tx pgx.Tx, teamName string, rules []domain.CodeOwnerRule) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM code_owner_rules WHERE team_name = $1`, teamName); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		var ruleID int64
+		err := tx.QueryRow(ctx,
+			`INSERT INTO code_owner_rules (team_name, pattern) VALUES ($1, $2) RETURNING id`,
+			teamName, rule.Pattern).Scan(&ruleID)
 		if err != nil {
-			continue
+			return err
 		}
-		pullRequests = append(pullRequests, pr)
+		for _, userID := range rule.Owners {
+			_, err := tx.Exec(ctx,
+				`INSERT INTO code_owner_rule_users (rule_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+				ruleID, userID)
+			if err != nil {
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+					return fmt.Errorf("%w: unknown user %q", app.ErrInvalidCodeOwner, userID)
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// insertEvents добавляет события в журнал внутри транзакции изменения.
+func insertEvents(ctx context.Context, tx pgx.Tx, events []domain.PREvent) error {
+	for _, e := range events {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO pr_events (pr_id, event_type, actor_id, reviewer_id, old_reviewer_id, details, created_at)
+			 VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7)`,
+			e.PullRequestID, e.Type, e.ActorID, e.ReviewerID, e.OldReviewerID, e.Details, e.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateReviewers заменяет ревьюеров PR внутри транзакции, если версия PR не изменилась.
+// Оставшиеся ревьюеры сохраняют состояние ревью, новые начинают с PENDING.
+func (r *PgRepository) updateReviewers(ctx context.Context, tx pgx.Tx, prID string, version int, reviewers []string, events []domain.PREvent) error {
+	if err := r.bumpVersion(ctx, tx, prID, version); err != nil {
+		return err
 	}
 
-	return pullRequests, nil
+	// Удаляем ревьюеров, которых нет в новом списке.
+	if _, err := tx.Exec(ctx, `DELETE FROM pr_reviewers WHERE pr_id = $1 AND NOT (reviewer_id = ANY($2))`,
+		prID, reviewers); err != nil {
+		return err
+	}
+
+	// Добавляем новых ревьюеров, сохраняя состояние ревью у оставшихся.
+	for _, reviewerID := range reviewers {
+		_, err := tx.Exec(ctx, `INSERT INTO pr_reviewers (pr_id, reviewer_id) VALUES ($1, $2)
+                               ON CONFLICT (pr_id, reviewer_id) DO NOTHING`, prID, reviewerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return insertEvents(ctx, tx, events)
+}
+
+// bumpVersion увеличивает версию PR внутри транзакции, если она совпадает с ожидаемой.
+func (r *PgRepository) bumpVersion(ctx context.Context, tx pgx.Tx, prID string, version int) error {
+	tag, err := tx.Exec(ctx,
+		`UPDATE pull_requests SET version = version + 1 WHERE pull_request_id = $1 AND version = $2`,
+		prID, version)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return r.versionError(ctx, prID)
+	}
+	return nil
+}
+
+// versionError объясняет, почему условное обновление PR не затронуло ни одной строки.
+func (r *PgRepository) versionError(ctx context.Context, prID string) error {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)`, prID).
+		Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return app.ErrPRNotFound
+	}
+	slog.DebugContext(ctx, "pull request version conflict", "pull_request_id", prID)
+	return app.ErrVersionConflict
+}
+
+func (r *PgRepository) ListPullRequests(ctx context.Context, filter domain.PRFilter) (domain.PRPage, error) {
+	q := &listQuery{}
+	if filter.AuthorID != "" {
+		q.add("pr.author_id = ?", filter.AuthorID)
+	}
+	if filter.TeamName != "" {
+		q.add("pr.team_name = ?", filter.TeamName)
+	}
+	if len(filter.Statuses) > 0 {
+		q.add("pr.status::text = ANY(?)", statusStrings(filter.Statuses))
+	}
+	if filter.ReviewerID != "" {
+		q.add("EXISTS (SELECT 1 FROM pr_reviewers f WHERE f.pr_id = pr.pull_request_id AND f.reviewer_id = ?)",
+			filter.ReviewerID)
+	}
+	if filter.NameContains != "" {
+		q.add("strpos(lower(pr.pull_request_name), lower(?)) > 0", filter.NameContains)
+	}
+	if filter.CreatedFrom != nil {
+		q.add("pr.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q.add("pr.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.MergedFrom != nil {
+		q.add("pr.merged_at >= ?", *filter.MergedFrom)
+	}
+	if filter.MergedTo != nil {
+		q.add("pr.merged_at < ?", *filter.MergedTo)
+	}
+	return r.listPullRequests(ctx, q, filter.Sort, filter.After, filter.Limit)
+}
+
+func (r *PgRepository) ListReviewerPullRequests(ctx context.Context, filter domain.ReviewerPRFilter) (domain.PRPage, error) {
+	q := &listQuery{}
+	reviewer := "EXISTS (SELECT 1 FROM pr_reviewers f WHERE f.pr_id = pr.pull_request_id AND f.reviewer_id = ?"
+	if filter.PendingOnly {
+		reviewer += " AND f.review_state = 'PENDING'"
+	}
+	q.add(reviewer+")", filter.ReviewerID)
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []domain.PRStatus{domain.StatusOpen}
+	}
+	q.add("pr.status::text = ANY(?)", statusStrings(statuses))
+	if filter.AuthorID != "" {
+		q.add("pr.author_id = ?", filter.AuthorID)
+	}
+	if filter.CreatedFrom != nil {
+		q.add("pr.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q.add("pr.created_at < ?", *filter.CreatedTo)
+	}
+	return r.listPullRequests(ctx, q, filter.Sort, filter.After, filter.Limit)
+}
+
+// listPullRequests выбирает страницу PR по условиям q в порядке sort, начиная сразу после after.
+// Ревьюеры собираются тем же запросом через array_agg, без отдельного чтения каждого PR.
+func (r *PgRepository) listPullRequests(ctx context.Context, q *listQuery, sort domain.PRSort, after *domain.PRCursor, limit int) (domain.PRPage, error) {
+	order, next := "DESC", "<"
+	if sort == domain.SortCreatedAsc {
+		order, next = "ASC", ">"
+	}
+	if after != nil {
+		q.add("(pr.created_at, pr.pull_request_id) "+next+" (?::timestamptz, ?::text)", after.CreatedAt, after.ID)
+	}
+	where := "TRUE"
+	if len(q.conds) > 0 {
+		where = strings.Join(q.conds, " AND ")
+	}
+	// LIMIT NULL снимает ограничение; лишний PR показывает, что есть следующая страница.
+	var rowLimit any
+	if limit > 0 {
+		rowLimit = limit + 1
+	}
+	q.args = append(q.args, rowLimit)
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(
+		`SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, COALESCE(pr.team_name, ''), pr.status,
+		        pr.changed_files, pr.required_reviewers, pr.created_at, pr.merged_at, pr.closed_at, pr.force_merged,
+		        pr.version,
+		        COALESCE(array_agg(a.reviewer_id ORDER BY a.reviewer_id) FILTER (WHERE a.reviewer_id IS NOT NULL), '{}'),
+		        COALESCE(array_agg(a.review_state::text ORDER BY a.reviewer_id) FILTER (WHERE a.reviewer_id IS NOT NULL), '{}')
+		 FROM pull_requests pr
+		 LEFT JOIN pr_reviewers a ON a.pr_id = pr.pull_request_id
+		 WHERE %s
+		 GROUP BY pr.pull_request_id
+		 ORDER BY pr.created_at %s, pr.pull_request_id %s
+		 LIMIT $%d`, where, order, order, len(q.args)),
+		q.args...)
+	if err != nil {
+		return domain.PRPage{}, err
+	}
+	defer rows.Close()
+
+	var prs []*domain.PullRequest
+	for rows.Next() {
+		pr := &domain.PullRequest{}
+		var states []string
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.TeamName, &pr.Status, &pr.ChangedFiles,
+			&pr.RequiredReviewers, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt, &pr.ForceMerged, &pr.Version,
+			&pr.AssignedReviewers, &states); err != nil {
+			return domain.PRPage{}, err
+		}
+		pr.ReviewStates = make(map[string]domain.ReviewState, len(states))
+		for i, reviewerID := range pr.AssignedReviewers {
+			pr.ReviewStates[reviewerID] = domain.ReviewState(states[i])
+		}
+		prs = append(prs, pr)
+	}
+	if rows.Err() != nil {
+		return domain.PRPage{}, rows.Err()
+	}
+	return domain.NewPRPage(prs, limit), nil
+}
+
+// listQuery накапливает условия WHERE списка PR и их параметры.
+type listQuery struct {
+	conds []string
+	args  []any
+}
+
+// add добавляет условие, заменяя в нем каждый "?" номером очередного параметра.
+func (q *listQuery) add(cond string, args ...any) {
+	for _, arg := range args {
+		q.args = append(q.args, arg)
+		cond = strings.Replace(cond, "?", "$"+strconv.Itoa(len(q.args)), 1)
+	}
+	q.conds = append(q.conds, cond)
+}
+
+// statusStrings переводит статусы в строки для сравнения с pr.status::text.
+func statusStrings(statuses []domain.PRStatus) []string {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+	return values
+}
+
+// secondsDuration переводит число секунд из SQL в длительность; NULL остается nil.
+func secondsDuration(seconds *float64) *time.Duration {
+	if seconds == nil {
+		return nil
+	}
+	d := time.Duration(*seconds * float64(time.Second))
+	return &d
+}
+
+// apiTokenColumns - колонки токена в порядке scanAPIToken.
+const apiTokenColumns = `token_id, name, role, COALESCE(user_id, ''), created_at, expires_at, revoked_at`
+
+func scanAPIToken(row pgx.Row) (domain.APIToken, error) {
+	var t domain.APIToken
+	err := row.Scan(&t.ID, &t.Name, &t.Role, &t.UserID, &t.CreatedAt, &t.ExpiresAt, &t.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.APIToken{}, app.ErrTokenNotFound
+	}
+	return t, err
+}
+
+func (r *PgRepository) CreateAPIToken(ctx context.Context, token domain.APIToken, tokenHash string) (domain.APIToken, error) {
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO api_tokens (name, token_hash, role, user_id, expires_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		 RETURNING token_id, created_at`,
+		token.Name, tokenHash, token.Role, token.UserID, token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+			return domain.APIToken{}, app.ErrUserNotFound
+		}
+		return domain.APIToken{}, err
+	}
+	return token, nil
+}
+
+func (r *PgRepository) GetAPITokenByHash(ctx context.Context, tokenHash string) (domain.APIToken, error) {
+	return scanAPIToken(r.db.QueryRow(ctx,
+		`SELECT `+apiTokenColumns+` FROM api_tokens WHERE token_hash = $1`, tokenHash))
+}
+
+func (r *PgRepository) GetAPIToken(ctx context.Context, tokenID int64) (domain.APIToken, error) {
+	return scanAPIToken(r.db.QueryRow(ctx,
+		`SELECT `+apiTokenColumns+` FROM api_tokens WHERE token_id = $1`, tokenID))
+}
+
+func (r *PgRepository) ListAPITokens(ctx context.Context, userID string) ([]domain.APIToken, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+apiTokenColumns+` FROM api_tokens WHERE $1 = '' OR user_id = $1 ORDER BY token_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]domain.APIToken, 0)
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *PgRepository) RevokeAPIToken(ctx context.Context, tokenID int64, at time.Time) (domain.APIToken, error) {
+	return scanAPIToken(r.db.QueryRow(ctx,
+		`UPDATE api_tokens SET revoked_at = COALESCE(revoked_at, $2) WHERE token_id = $1
+		 RETURNING `+apiTokenColumns, tokenID, at))
+}
+
+func (r *PgRepository) ReserveIdempotencyKey(ctx context.Context, key, requestHash string, staleBefore, expiredBefore time.Time) (*domain.IdempotencyRecord, bool, error) {
+	// Пытаемся занять ключ или перехватить устаревший; при конфликте с действующим ключом вставка ничего не изменит.
+	tag, err := r.db.Exec(ctx,
+		`INSERT INTO idempotency_keys (idempotency_key, request_hash) VALUES ($1, $2)
+		 ON CONFLICT (idempotency_key) DO UPDATE
+		 SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_body = NULL,
+		     content_type = NULL, etag = NULL, created_at = NOW()
+		 WHERE (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $3)
+		    OR idempotency_keys.created_at < $4`,
+		key, requestHash, staleBefore, expiredBefore)
+	if err != nil {
+		return nil, false, err
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	// Ключ уже занят - возвращаем сохраненную запись.
+	record := &domain.IdempotencyRecord{Key: key}
+	var statusCode *int
+	var contentType, etag *string
+	err = r.db.QueryRow(ctx,
+		`SELECT request_hash, status_code, content_type, etag, response_body, created_at
+		 FROM idempotency_keys WHERE idempotency_key = $1`,
+		key).Scan(&record.RequestHash, &statusCode, &contentType, &etag, &record.Body, &record.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Ключ успели освободить между запросами - пробуем занять заново.
+			return r.ReserveIdempotencyKey(ctx, key, requestHash, staleBefore, expiredBefore)
+		}
+		return nil, false, err
+	}
+	if statusCode != nil {
+		record.StatusCode = *statusCode
+	}
+	if contentType != nil {
+		record.ContentType = *contentType
+	}
+	if etag != nil {
+		record.ETag = *etag
+	}
+	return record, false, nil
+}
+
+func (r *PgRepository) SaveIdempotencyResponse(ctx context.Context, response domain.IdempotencyRecord) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE idempotency_keys SET status_code = $1, content_type = NULLIF($2, ''), etag = NULLIF($3, ''), response_body = $4
+		 WHERE idempotency_key = $5`,
+		response.StatusCode, response.ContentType, response.ETag, response.Body, response.Key)
+	return err
+}
+
+func (r *PgRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE idempotency_key = $1`, key)
+	return err
+}
+
+func (r *PgRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/wsppppp/manage-pull-request/internal/domain"
 )
@@ -11,15 +12,94 @@ type Repository interface {
 	// команды
 	CreateTeam(ctx context.Context, team domain.Team) (domain.Team, error)
 	GetTeamByName(ctx context.Context, teamName string) (domain.Team, error)
+	UpdateTeamSettings(ctx context.Context, teamName string, settings domain.TeamSettings) (domain.TeamSettings, error)
+	// SetReviewerPools заменяет пулы ревьюеров команды. Возвращает app.ErrNotFound, если команды нет,
+	// и app.ErrInvalidReviewerPool, если пул ссылается на несуществующую команду или пользователя.
+	SetReviewerPools(ctx context.Context, teamName string, pools []domain.ReviewerPool) error
+	// SetCodeOwners заменяет правила владения кодом команды, сохраняя их порядок. Возвращает app.ErrNotFound,
+	// если команды нет, и app.ErrInvalidCodeOwner, если правило ссылается на несуществующего пользователя.
+	SetCodeOwners(ctx context.Context, teamName string, rules []domain.CodeOwnerRule) error
+	// AddTeamMember создает пользователя или добавляет существующего в команду, сохраняя его прежние команды.
+	AddTeamMember(ctx context.Context, teamName string, member domain.User) error
+	// RemoveTeamMember в одной транзакции исключает пользователя из команды и применяет замены ревьюеров.
+	// Возвращает app.ErrNotFound, если команды нет, app.ErrNotTeamMember, если пользователь в ней не состоит,
+	// и app.ErrVersionConflict, если PR изменился после составления замен; в этих случаях ничего не меняется.
+	RemoveTeamMember(ctx context.Context, teamName, userID string, updates []domain.ReviewerUpdate) error
+	RenameTeam(ctx context.Context, teamName, newName string) error
+	// DeleteTeam удаляет команду вместе с пулами ревьюеров, которые на неё ссылаются.
+	// Возвращает app.ErrTeamHasOpenPRs, если в команде есть открытые PR или черновики.
+	DeleteTeam(ctx context.Context, teamName string) error
 
 	// юзеры
 	SetUserActivity(ctx context.Context, userID string, isActive bool) (*domain.User, error)
 	GetUserByID(ctx context.Context, userID string) (*domain.User, error)
+	CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error)
+	// DeactivateUsers в одной транзакции снимает с пользователей флаг активности и применяет замены ревьюеров.
+	// Возвращает app.ErrUserNotFound, если пользователя нет, и app.ErrVersionConflict, если PR изменился
+	// после составления замен; в этих случаях ничего не меняется.
+	DeactivateUsers(ctx context.Context, userIDs []string, updates []domain.ReviewerUpdate) error
+
+	// отсутствия
+	// CreateAbsence сохраняет период отсутствия. Возвращает app.ErrUserNotFound, если пользователя нет.
+	CreateAbsence(ctx context.Context, absence domain.Absence) (domain.Absence, error)
+	// GetAbsences возвращает периоды отсутствия пользователя в порядке начала.
+	GetAbsences(ctx context.Context, userID string) ([]domain.Absence, error)
+	// DeleteAbsence удаляет период отсутствия и возвращает его. Возвращает app.ErrAbsenceNotFound, если его нет.
+	DeleteAbsence(ctx context.Context, absenceID int64) (domain.Absence, error)
+	// GetAbsence возвращает период отсутствия. Возвращает app.ErrAbsenceNotFound, если его нет.
+	GetAbsence(ctx context.Context, absenceID int64) (domain.Absence, error)
+	// AbsentUsers возвращает тех из userIDs, кто отсутствует в момент at.
+	AbsentUsers(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error)
 
 	// pr
-	CreatePullRequest(ctx context.Context, pr domain.PullRequest) (*domain.PullRequest, error)
+	CreatePullRequest(ctx context.Context, pr domain.PullRequest, events ...domain.PREvent) (*domain.PullRequest, error)
 	GetPullRequestByID(ctx context.Context, prID string) (*domain.PullRequest, error)
-	MergePullRequest(ctx context.Context, prID string) (*domain.PullRequest, error)
-	UpdatePullRequestReviewers(ctx context.Context, prID string, reviewers []string) error
-	GetOpenPullRequestsByReviewer(ctx context.Context, userID string) ([]*domain.PullRequest, error)
+
+	// Изменяющие методы принимают версию PR, прочитанную сервисом, и возвращают
+	// app.ErrVersionConflict, если PR успели изменить. События записываются в журнал
+	// в той же транзакции, что и само изменение.
+	// MergePullRequest возвращает merged = true, только если PR слил именно этот вызов;
+	// для уже слитого PR возвращается его текущее состояние и merged = false.
+	MergePullRequest(ctx context.Context, prID string, version int, forced bool, events ...domain.PREvent) (pr *domain.PullRequest, merged bool, err error)
+	UpdatePullRequestStatus(ctx context.Context, prID string, version int, to domain.PRStatus, reviewers []string, events ...domain.PREvent) (*domain.PullRequest, error)
+	UpdatePullRequestReviewers(ctx context.Context, prID string, version int, reviewers []string, events ...domain.PREvent) error
+	SetReviewState(ctx context.Context, prID string, version int, reviewerID string, state domain.ReviewState, events ...domain.PREvent) error
+	// ListPullRequests возвращает страницу PR по условиям filter вместе с их ревьюерами, в порядке filter.Sort.
+	ListPullRequests(ctx context.Context, filter domain.PRFilter) (domain.PRPage, error)
+	// ListReviewerPullRequests возвращает страницу PR, на которые назначен filter.ReviewerID,
+	// вместе с их ревьюерами, в порядке filter.Sort.
+	ListReviewerPullRequests(ctx context.Context, filter domain.ReviewerPRFilter) (domain.PRPage, error)
+	GetPullRequestEvents(ctx context.Context, prID string) ([]domain.PREvent, error)
+
+	// статистика
+	// ReviewerStats возвращает нагрузку ревьюеров, упорядоченную по ID пользователя. В выдачу попадают
+	// только пользователи, которых за период назначали ревьюерами или заменяли другими.
+	ReviewerStats(ctx context.Context, filter domain.StatsFilter) ([]domain.ReviewerStats, error)
+	// TeamStats возвращает статистику PR по командам, упорядоченную по имени команды.
+	TeamStats(ctx context.Context, filter domain.StatsFilter) ([]domain.TeamStats, error)
+
+	// токены API
+	// CreateAPIToken сохраняет токен с хешем секрета tokenHash и возвращает его с ID и временем создания.
+	// Возвращает app.ErrUserNotFound, если пользователя токена нет.
+	CreateAPIToken(ctx context.Context, token domain.APIToken, tokenHash string) (domain.APIToken, error)
+	// GetAPITokenByHash возвращает токен по хешу секрета. Возвращает app.ErrTokenNotFound, если его нет.
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (domain.APIToken, error)
+	// GetAPIToken возвращает токен по ID. Возвращает app.ErrTokenNotFound, если его нет.
+	GetAPIToken(ctx context.Context, tokenID int64) (domain.APIToken, error)
+	// ListAPITokens возвращает токены пользователя userID, а при пустом userID - все токены, в порядке ID.
+	ListAPITokens(ctx context.Context, userID string) ([]domain.APIToken, error)
+	// RevokeAPIToken отзывает токен в момент at и возвращает его. Уже отозванный токен не меняется.
+	// Возвращает app.ErrTokenNotFound, если токена нет.
+	RevokeAPIToken(ctx context.Context, tokenID int64, at time.Time) (domain.APIToken, error)
+
+	// идемпотентность
+	// ReserveIdempotencyKey занимает ключ. Если ключ уже занят, возвращает существующую запись и false.
+	// Ключ, занятый раньше staleBefore и так и не получивший ответа, или занятый раньше expiredBefore,
+	// считается свободным и занимается заново.
+	ReserveIdempotencyKey(ctx context.Context, key, requestHash string, staleBefore, expiredBefore time.Time) (*domain.IdempotencyRecord, bool, error)
+	// SaveIdempotencyResponse сохраняет статус, заголовки и тело ответа для ключа response.Key.
+	SaveIdempotencyResponse(ctx context.Context, response domain.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	// DeleteExpiredIdempotencyKeys удаляет ключи, занятые раньше before, и возвращает их число.
+	DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
 }
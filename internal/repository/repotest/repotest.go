@@ -0,0 +1,1344 @@
+// Package repotest проверяет, что реализация repository.Repository соблюдает общий контракт:
+// ошибки app, версии PR, журнал событий и идемпотентность.
+//
+// Каждая проверка запускается отдельным подтестом на репозитории, который возвращает newRepo:
+//
+//	func TestRepository(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repository.Repository { return memory.New() })
+//	}
+//
+// Все сущности создаются с уникальным префиксом, так что проверки можно запускать
+// на непустой базе PostgreSQL и на одном репозитории для всех подтестов.
+package repotest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/repository"
+)
+
+// concurrentWriters - число горутин, одновременно изменяющих один PR.
+const concurrentWriters = 8
+
+type check struct {
+	name string
+	run  func(ctx context.Context, s *suite) error
+}
+
+var checks = []check{
+	{"teams", checkTeams},
+	{"users", checkUsers},
+	{"membership", checkMembership},
+	{"multiple teams", checkMultipleTeams},
+	{"reviewer pools", checkReviewerPools},
+	{"code owners", checkCodeOwners},
+	{"deactivation", checkDeactivation},
+	{"absences", checkAbsences},
+	{"pull requests", checkPullRequests},
+	{"reviewer lists", checkReviewerLists},
+	{"pull request lists", checkPullRequestLists},
+	{"stats", checkStats},
+	{"versions", checkVersions},
+	{"merge", checkMerge},
+	{"status", checkStatus},
+	{"events", checkEvents},
+	{"idempotency", checkIdempotency},
+	{"api tokens", checkAPITokens},
+	{"concurrent updates", checkConcurrentUpdates},
+}
+
+type suite struct {
+	repo   repository.Repository
+	prefix string
+}
+
+// Run выполняет каждую проверку контракта в отдельном подтесте.
+// newRepo вызывается для каждого подтеста и может возвращать как новый, так и общий репозиторий.
+func Run(t *testing.T, newRepo func(t *testing.T) repository.Repository) {
+	prefix := fmt.Sprintf("repotest-%d-", time.Now().UnixNano())
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			s := &suite{repo: newRepo(t), prefix: prefix}
+			if err := c.run(t.Context(), s); err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+		})
+	}
+}
+
+// id возвращает уникальный для запуска идентификатор.
+func (s *suite) id(name string) string {
+	return s.prefix + name
+}
+
+// team создает команду с активными участниками и возвращает их ID.
+func (s *suite) team(ctx context.Context, name string, members ...string) (domain.Team, error) {
+	team := domain.Team{Name: s.id(name), TeamSettings: domain.DefaultTeamSettings()}
+	team.ReviewerStrategy = domain.StrategyRandom
+	for _, member := range members {
+		team.Members = append(team.Members, domain.User{
+			ID: s.id(member), Username: member, TeamName: team.Name, IsActive: true, ReviewWeight: 1,
+		})
+	}
+	return s.repo.CreateTeam(ctx, team)
+}
+
+// pr создает открытый PR автора в его основной команде с указанными ревьюерами.
+func (s *suite) pr(ctx context.Context, name, author string, reviewers ...string) (*domain.PullRequest, error) {
+	user, err := s.repo.GetUserByID(ctx, s.id(author))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		ids = append(ids, s.id(reviewer))
+	}
+	return s.repo.CreatePullRequest(ctx, domain.PullRequest{
+		ID:                s.id(name),
+		Name:              name,
+		AuthorID:          s.id(author),
+		TeamName:          user.TeamName,
+		Status:            domain.StatusOpen,
+		AssignedReviewers: ids,
+		RequiredReviewers: len(ids),
+		CreatedAt:         time.Now(),
+	})
+}
+
+func checkTeams(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "backend", "alice", "bob"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	var exists *app.ErrTeamExists
+	if _, err := s.team(ctx, "backend"); !errors.As(err, &exists) {
+		return fmt.Errorf("duplicate team: got %v, want ErrTeamExists", err)
+	}
+
+	team, err := s.repo.GetTeamByName(ctx, s.id("backend"))
+	if err != nil {
+		return fmt.Errorf("get team: %w", err)
+	}
+	if len(team.Members) != 2 {
+		return fmt.Errorf("get team: got %d members, want 2", len(team.Members))
+	}
+	if team.ReviewerStrategy != domain.StrategyRandom || team.MaxReviewers != domain.DefaultMaxReviewers {
+		return fmt.Errorf("get team: settings not stored: %+v", team.TeamSettings)
+	}
+
+	if _, err := s.repo.GetTeamByName(ctx, s.id("missing")); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("missing team: got %v, want ErrNotFound", err)
+	}
+
+	settings := team.TeamSettings
+	settings.MaxReviewers = 3
+	updated, err := s.repo.UpdateTeamSettings(ctx, team.Name, settings)
+	if err != nil {
+		return fmt.Errorf("update settings: %w", err)
+	}
+	if updated.MaxReviewers != 3 {
+		return fmt.Errorf("update settings: got max_reviewers %d, want 3", updated.MaxReviewers)
+	}
+	if _, err := s.repo.UpdateTeamSettings(ctx, s.id("missing"), settings); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("update missing team: got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func checkUsers(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "users", "carol"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	user, err := s.repo.SetUserActivity(ctx, s.id("carol"), false)
+	if err != nil {
+		return fmt.Errorf("set activity: %w", err)
+	}
+	if user.IsActive || user.TeamName != s.id("users") {
+		return fmt.Errorf("set activity: got %+v", user)
+	}
+
+	user, err = s.repo.GetUserByID(ctx, s.id("carol"))
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if user.IsActive {
+		return errors.New("get user: activity change was not stored")
+	}
+
+	if _, err := s.repo.GetUserByID(ctx, s.id("nobody")); !errors.Is(err, app.ErrUserNotFound) {
+		return fmt.Errorf("missing user: got %v, want ErrUserNotFound", err)
+	}
+	if _, err := s.repo.SetUserActivity(ctx, s.id("nobody"), true); !errors.Is(err, app.ErrUserNotFound) {
+		return fmt.Errorf("set activity of missing user: got %v, want ErrUserNotFound", err)
+	}
+	return nil
+}
+
+func checkMembership(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "members", "sam"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	team := s.id("members")
+	newcomer := domain.User{ID: s.id("tina"), Username: "tina", IsActive: true, ReviewWeight: 1}
+
+	if err := s.repo.AddTeamMember(ctx, team, newcomer); err != nil {
+		return fmt.Errorf("add member: %w", err)
+	}
+	if err := s.repo.AddTeamMember(ctx, s.id("missing"), newcomer); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("add member to missing team: got %v, want ErrNotFound", err)
+	}
+
+	reviewed, err := s.pr(ctx, "pr-members-leaving", "sam", "tina")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+
+	// Устаревшая версия PR откатывает всю операцию, включая исключение из команды.
+	update := domain.ReviewerUpdate{PullRequestID: reviewed.ID, Version: reviewed.Version + 1, Reviewers: []string{}}
+	if err := s.repo.RemoveTeamMember(ctx, team, newcomer.ID, []domain.ReviewerUpdate{update}); !errors.Is(err, app.ErrVersionConflict) {
+		return fmt.Errorf("remove member with stale version: got %v, want ErrVersionConflict", err)
+	}
+	if user, err := s.repo.GetUserByID(ctx, newcomer.ID); err != nil || user.TeamName != team {
+		return fmt.Errorf("failed removal changed the member: %+v, %v", user, err)
+	}
+
+	update.Version = reviewed.Version
+	if err := s.repo.RemoveTeamMember(ctx, team, newcomer.ID, []domain.ReviewerUpdate{update}); err != nil {
+		return fmt.Errorf("remove member: %w", err)
+	}
+	if stored, err := s.repo.GetPullRequestByID(ctx, reviewed.ID); err != nil || len(stored.AssignedReviewers) != 0 {
+		return fmt.Errorf("remove member: reviewers were not updated: %+v, %v", stored, err)
+	}
+	user, err := s.repo.GetUserByID(ctx, newcomer.ID)
+	if err != nil {
+		return fmt.Errorf("get removed member: %w", err)
+	}
+	if user.TeamName != "" {
+		return fmt.Errorf("removed member still belongs to %q", user.TeamName)
+	}
+	if err := s.repo.RemoveTeamMember(ctx, team, newcomer.ID, nil); !errors.Is(err, app.ErrNotTeamMember) {
+		return fmt.Errorf("remove non-member: got %v, want ErrNotTeamMember", err)
+	}
+	if err := s.repo.RemoveTeamMember(ctx, s.id("missing"), newcomer.ID, nil); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("remove member of missing team: got %v, want ErrNotFound", err)
+	}
+
+	if _, err := s.team(ctx, "members-taken"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	var exists *app.ErrTeamExists
+	if err := s.repo.RenameTeam(ctx, team, s.id("members-taken")); !errors.As(err, &exists) {
+		return fmt.Errorf("rename to taken name: got %v, want ErrTeamExists", err)
+	}
+	renamed := s.id("members-renamed")
+	if err := s.repo.RenameTeam(ctx, team, renamed); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	if user, err := s.repo.GetUserByID(ctx, s.id("sam")); err != nil || user.TeamName != renamed {
+		return fmt.Errorf("rename: member did not follow the team: %+v, %v", user, err)
+	}
+	if err := s.repo.RenameTeam(ctx, team, s.id("other")); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("rename missing team: got %v, want ErrNotFound", err)
+	}
+
+	if _, err := s.pr(ctx, "pr-members", "sam"); err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+	if err := s.repo.DeleteTeam(ctx, renamed); !errors.Is(err, app.ErrTeamHasOpenPRs) {
+		return fmt.Errorf("delete team with open pr: got %v, want ErrTeamHasOpenPRs", err)
+	}
+	if err := s.repo.DeleteTeam(ctx, s.id("members-taken")); err != nil {
+		return fmt.Errorf("delete team: %w", err)
+	}
+	if _, err := s.repo.GetTeamByName(ctx, s.id("members-taken")); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("deleted team: got %v, want ErrNotFound", err)
+	}
+	if err := s.repo.DeleteTeam(ctx, s.id("members-taken")); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("delete missing team: got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func checkMultipleTeams(ctx context.Context, s *suite) error {
+	first, second := s.id("multi-a"), s.id("multi-b")
+	if _, err := s.team(ctx, "multi-a", "uma"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	if _, err := s.team(ctx, "multi-b", "victor"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	// Атрибуты уже существующего пользователя при вступлении в другую команду не меняются.
+	uma := domain.User{ID: s.id("uma"), Username: "uma-renamed", IsActive: false, ReviewWeight: 5}
+	if err := s.repo.AddTeamMember(ctx, second, uma); err != nil {
+		return fmt.Errorf("add member: %w", err)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, uma.ID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if user.TeamName != first || !slices.Equal(user.Teams, []string{first, second}) {
+		return fmt.Errorf("add member: user moved instead of joining: team %q, teams %v", user.TeamName, user.Teams)
+	}
+	if user.Username != "uma" || !user.IsActive || user.ReviewWeight != 1 {
+		return fmt.Errorf("add member: existing user attributes changed: %+v", user)
+	}
+	for _, teamName := range []string{first, second} {
+		team, err := s.repo.GetTeamByName(ctx, teamName)
+		if err != nil {
+			return fmt.Errorf("get team: %w", err)
+		}
+		if !slices.ContainsFunc(team.Members, func(member domain.User) bool { return member.ID == uma.ID }) {
+			return fmt.Errorf("get team %s: user is not listed as a member", teamName)
+		}
+	}
+
+	pr, err := s.pr(ctx, "pr-multi", "uma")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+	if pr, err = s.repo.GetPullRequestByID(ctx, pr.ID); err != nil || pr.TeamName != first {
+		return fmt.Errorf("get pr: team not stored: %+v, %v", pr, err)
+	}
+	renamed := s.id("multi-a-renamed")
+	if err := s.repo.RenameTeam(ctx, first, renamed); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	if pr, err = s.repo.GetPullRequestByID(ctx, pr.ID); err != nil || pr.TeamName != renamed {
+		return fmt.Errorf("rename: pr did not follow the team: %+v, %v", pr, err)
+	}
+
+	// Уход из основной команды делает основной одну из оставшихся.
+	if err := s.repo.RemoveTeamMember(ctx, renamed, uma.ID, nil); err != nil {
+		return fmt.Errorf("remove member: %w", err)
+	}
+	user, err = s.repo.GetUserByID(ctx, uma.ID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if user.TeamName != second || !slices.Equal(user.Teams, []string{second}) {
+		return fmt.Errorf("remove member: got team %q, teams %v", user.TeamName, user.Teams)
+	}
+
+	if err := s.repo.DeleteTeam(ctx, second); err != nil {
+		return fmt.Errorf("delete team: %w", err)
+	}
+	user, err = s.repo.GetUserByID(ctx, uma.ID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if user.TeamName != "" || len(user.Teams) != 0 {
+		return fmt.Errorf("delete team: user still has team %q, teams %v", user.TeamName, user.Teams)
+	}
+	return nil
+}
+
+func checkReviewerPools(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "pools", "wade"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	if _, err := s.team(ctx, "pools-source", "xena"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	team, source := s.id("pools"), s.id("pools-source")
+
+	pools := []domain.ReviewerPool{
+		{Mode: domain.PoolModeAlways, TeamName: source},
+		{Mode: domain.PoolModeFallback, UserIDs: []string{s.id("xena"), s.id("wade")}},
+	}
+	if err := s.repo.SetReviewerPools(ctx, team, pools); err != nil {
+		return fmt.Errorf("set pools: %w", err)
+	}
+	stored, err := s.repo.GetTeamByName(ctx, team)
+	if err != nil {
+		return fmt.Errorf("get team: %w", err)
+	}
+	if len(stored.ReviewerPools) != 2 ||
+		stored.ReviewerPools[0].Mode != domain.PoolModeAlways || stored.ReviewerPools[0].TeamName != source ||
+		stored.ReviewerPools[1].Mode != domain.PoolModeFallback ||
+		!slices.Equal(stored.ReviewerPools[1].UserIDs, []string{s.id("wade"), s.id("xena")}) {
+		return fmt.Errorf("get team: got pools %+v", stored.ReviewerPools)
+	}
+
+	if err := s.repo.SetReviewerPools(ctx, s.id("missing"), pools); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("set pools of missing team: got %v, want ErrNotFound", err)
+	}
+	invalid := [][]domain.ReviewerPool{
+		{{Mode: domain.PoolModeAlways, TeamName: s.id("missing")}},
+		{{Mode: domain.PoolModeAlways, UserIDs: []string{s.id("nobody")}}},
+	}
+	for _, pools := range invalid {
+		if err := s.repo.SetReviewerPools(ctx, team, pools); !errors.Is(err, app.ErrInvalidReviewerPool) {
+			return fmt.Errorf("set pools %+v: got %v, want ErrInvalidReviewerPool", pools, err)
+		}
+	}
+	if stored, err = s.repo.GetTeamByName(ctx, team); err != nil || len(stored.ReviewerPools) != 2 {
+		return fmt.Errorf("failed set pools changed stored pools: %+v, %v", stored.ReviewerPools, err)
+	}
+
+	renamed := s.id("pools-source-renamed")
+	if err := s.repo.RenameTeam(ctx, source, renamed); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	if stored, err = s.repo.GetTeamByName(ctx, team); err != nil || stored.ReviewerPools[0].TeamName != renamed {
+		return fmt.Errorf("rename: pool did not follow the team: %+v, %v", stored.ReviewerPools, err)
+	}
+	if err := s.repo.DeleteTeam(ctx, renamed); err != nil {
+		return fmt.Errorf("delete team: %w", err)
+	}
+	if stored, err = s.repo.GetTeamByName(ctx, team); err != nil || len(stored.ReviewerPools) != 1 {
+		return fmt.Errorf("delete team: pool of deleted team left: %+v, %v", stored.ReviewerPools, err)
+	}
+	return nil
+}
+
+func checkCodeOwners(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "owners", "gus", "hana"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	team := s.id("owners")
+
+	// Порядок правил важен: при совпадении нескольких действует последнее.
+	rules := []domain.CodeOwnerRule{
+		{Pattern: "*", Owners: []string{s.id("hana"), s.id("gus")}},
+		{Pattern: "/docs/", Owners: []string{s.id("hana")}},
+		{Pattern: "*.md", Owners: []string{}},
+	}
+	if err := s.repo.SetCodeOwners(ctx, team, rules); err != nil {
+		return fmt.Errorf("set code owners: %w", err)
+	}
+	stored, err := s.repo.GetTeamByName(ctx, team)
+	if err != nil {
+		return fmt.Errorf("get team: %w", err)
+	}
+	if len(stored.CodeOwners) != 3 ||
+		stored.CodeOwners[0].Pattern != "*" || !slices.Equal(stored.CodeOwners[0].Owners, []string{s.id("gus"), s.id("hana")}) ||
+		stored.CodeOwners[1].Pattern != "/docs/" || stored.CodeOwners[2].Pattern != "*.md" ||
+		stored.CodeOwners[2].Owners == nil || len(stored.CodeOwners[2].Owners) != 0 {
+		return fmt.Errorf("get team: got code owners %+v", stored.CodeOwners)
+	}
+
+	if err := s.repo.SetCodeOwners(ctx, s.id("missing"), rules); !errors.Is(err, app.ErrNotFound) {
+		return fmt.Errorf("set code owners of missing team: got %v, want ErrNotFound", err)
+	}
+	invalid := []domain.CodeOwnerRule{{Pattern: "*.go", Owners: []string{s.id("nobody")}}}
+	if err := s.repo.SetCodeOwners(ctx, team, invalid); !errors.Is(err, app.ErrInvalidCodeOwner) {
+		return fmt.Errorf("set code owners %+v: got %v, want ErrInvalidCodeOwner", invalid, err)
+	}
+	if stored, err = s.repo.GetTeamByName(ctx, team); err != nil || len(stored.CodeOwners) != 3 {
+		return fmt.Errorf("failed set code owners changed stored rules: %+v, %v", stored.CodeOwners, err)
+	}
+
+	renamed := s.id("owners-renamed")
+	if err := s.repo.RenameTeam(ctx, team, renamed); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	if stored, err = s.repo.GetTeamByName(ctx, renamed); err != nil || len(stored.CodeOwners) != 3 {
+		return fmt.Errorf("rename: rules did not follow the team: %+v, %v", stored.CodeOwners, err)
+	}
+
+	// Измененные файлы сохраняются вместе с PR в исходном порядке.
+	files := []string{"docs/readme.md", "cmd/app/main.go"}
+	created, err := s.repo.CreatePullRequest(ctx, domain.PullRequest{
+		ID: s.id("pr-owners"), Name: "owners", AuthorID: s.id("gus"), TeamName: renamed,
+		Status: domain.StatusOpen, ChangedFiles: files, CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+	pr, err := s.repo.GetPullRequestByID(ctx, created.ID)
+	if err != nil || !slices.Equal(pr.ChangedFiles, files) {
+		return fmt.Errorf("get pr: got changed files %v, %v", pr, err)
+	}
+	if pr, err = s.pr(ctx, "pr-owners-none", "gus"); err != nil {
+		return fmt.Errorf("create pr without files: %w", err)
+	}
+	if pr, err = s.repo.GetPullRequestByID(ctx, pr.ID); err != nil || pr.ChangedFiles == nil || len(pr.ChangedFiles) != 0 {
+		return fmt.Errorf("get pr without files: got %v, %v", pr, err)
+	}
+	return nil
+}
+
+func checkDeactivation(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "deactivation", "abe", "yara", "zack"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	pr, err := s.pr(ctx, "pr-deactivation", "abe", "yara")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+
+	update := domain.ReviewerUpdate{PullRequestID: pr.ID, Version: pr.Version, Reviewers: []string{s.id("zack")}}
+	if err := s.repo.DeactivateUsers(ctx, []string{s.id("yara")}, []domain.ReviewerUpdate{update}); err != nil {
+		return fmt.Errorf("deactivate: %w", err)
+	}
+	if user, err := s.repo.GetUserByID(ctx, s.id("yara")); err != nil || user.IsActive {
+		return fmt.Errorf("deactivate: user is still active: %+v, %v", user, err)
+	}
+	stored, err := s.repo.GetPullRequestByID(ctx, pr.ID)
+	if err != nil {
+		return fmt.Errorf("get pr: %w", err)
+	}
+	if stored.Version != pr.Version+1 || !slices.Equal(stored.AssignedReviewers, []string{s.id("zack")}) {
+		return fmt.Errorf("deactivate: got version %d and reviewers %v", stored.Version, stored.AssignedReviewers)
+	}
+
+	// Добавление в команду не деактивирует ревьюера в обход замен на его открытых PR.
+	if _, err := s.team(ctx, "deactivation-other"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	inactive := domain.User{ID: s.id("zack"), Username: "zack", IsActive: false, ReviewWeight: 1}
+	if err := s.repo.AddTeamMember(ctx, s.id("deactivation-other"), inactive); err != nil {
+		return fmt.Errorf("add member: %w", err)
+	}
+	if user, err := s.repo.GetUserByID(ctx, s.id("zack")); err != nil || !user.IsActive {
+		return fmt.Errorf("add member deactivated the reviewer: %+v, %v", user, err)
+	}
+
+	// Устаревшая версия откатывает всю операцию, включая деактивацию.
+	if err := s.repo.DeactivateUsers(ctx, []string{s.id("zack")}, []domain.ReviewerUpdate{update}); !errors.Is(err, app.ErrVersionConflict) {
+		return fmt.Errorf("deactivate with stale version: got %v, want ErrVersionConflict", err)
+	}
+	if user, err := s.repo.GetUserByID(ctx, s.id("zack")); err != nil || !user.IsActive {
+		return fmt.Errorf("failed deactivation changed the user: %+v, %v", user, err)
+	}
+	if err := s.repo.DeactivateUsers(ctx, []string{s.id("zack"), s.id("nobody")}, nil); !errors.Is(err, app.ErrUserNotFound) {
+		return fmt.Errorf("deactivate missing user: got %v, want ErrUserNotFound", err)
+	}
+	if user, err := s.repo.GetUserByID(ctx, s.id("zack")); err != nil || !user.IsActive {
+		return fmt.Errorf("failed deactivation changed the user: %+v, %v", user, err)
+	}
+	if err := s.repo.DeactivateUsers(ctx, []string{s.id("abe"), s.id("abe")}, nil); err != nil {
+		return fmt.Errorf("deactivate repeated user: %w", err)
+	}
+	if user, err := s.repo.GetUserByID(ctx, s.id("abe")); err != nil || user.IsActive {
+		return fmt.Errorf("deactivate repeated user: user is still active: %+v, %v", user, err)
+	}
+	return nil
+}
+
+func checkAbsences(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "absences", "bea"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	userID := s.id("bea")
+	now := time.Now().Truncate(time.Second)
+
+	later, err := s.repo.CreateAbsence(ctx, domain.Absence{
+		UserID: userID, StartsAt: now.Add(24 * time.Hour), EndsAt: now.Add(48 * time.Hour), Reason: "vacation",
+	})
+	if err != nil {
+		return fmt.Errorf("create absence: %w", err)
+	}
+	current, err := s.repo.CreateAbsence(ctx, domain.Absence{
+		UserID: userID, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		return fmt.Errorf("create absence: %w", err)
+	}
+	if _, err := s.repo.CreateAbsence(ctx, domain.Absence{
+		UserID: s.id("nobody"), StartsAt: now, EndsAt: now.Add(time.Hour),
+	}); !errors.Is(err, app.ErrUserNotFound) {
+		return fmt.Errorf("absence of missing user: got %v, want ErrUserNotFound", err)
+	}
+
+	absences, err := s.repo.GetAbsences(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get absences: %w", err)
+	}
+	if len(absences) != 2 || absences[0].ID != current.ID || absences[1].ID != later.ID ||
+		!absences[1].StartsAt.Equal(later.StartsAt) || absences[1].Reason != "vacation" {
+		return fmt.Errorf("get absences: got %+v", absences)
+	}
+
+	// Момент проверки в другом часовом поясе не должен влиять на результат.
+	at := now.In(time.FixedZone("UTC+5", 5*60*60))
+	absent, err := s.repo.AbsentUsers(ctx, []string{userID, s.id("nobody")}, at)
+	if err != nil {
+		return fmt.Errorf("absent users: %w", err)
+	}
+	if !absent[userID] || len(absent) != 1 {
+		return fmt.Errorf("absent users: got %v", absent)
+	}
+	if absent, err = s.repo.AbsentUsers(ctx, []string{userID}, now.Add(2*time.Hour)); err != nil || len(absent) != 0 {
+		return fmt.Errorf("absent users between absences: got %v, %v", absent, err)
+	}
+
+	if stored, err := s.repo.GetAbsence(ctx, later.ID); err != nil || stored.UserID != userID || stored.Reason != "vacation" {
+		return fmt.Errorf("get absence: got %+v, %v", stored, err)
+	}
+	if _, err := s.repo.DeleteAbsence(ctx, current.ID); err != nil {
+		return fmt.Errorf("delete absence: %w", err)
+	}
+	if _, err := s.repo.GetAbsence(ctx, current.ID); !errors.Is(err, app.ErrAbsenceNotFound) {
+		return fmt.Errorf("get deleted absence: got %v, want ErrAbsenceNotFound", err)
+	}
+	if _, err := s.repo.DeleteAbsence(ctx, current.ID); !errors.Is(err, app.ErrAbsenceNotFound) {
+		return fmt.Errorf("delete missing absence: got %v, want ErrAbsenceNotFound", err)
+	}
+	if absent, err = s.repo.AbsentUsers(ctx, []string{userID}, now); err != nil || len(absent) != 0 {
+		return fmt.Errorf("absent users after cancel: got %v, %v", absent, err)
+	}
+	return nil
+}
+
+func checkPullRequests(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "prs", "dan", "erin", "frank"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	created, err := s.pr(ctx, "pr-create", "dan", "frank", "erin")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+	if created.Version != 1 {
+		return fmt.Errorf("create pr: got version %d, want 1", created.Version)
+	}
+
+	if _, err := s.pr(ctx, "pr-create", "dan"); !errors.Is(err, app.ErrPRExists) {
+		return fmt.Errorf("duplicate pr: got %v, want ErrPRExists", err)
+	}
+	if _, err := s.repo.GetPullRequestByID(ctx, s.id("pr-missing")); !errors.Is(err, app.ErrPRNotFound) {
+		return fmt.Errorf("missing pr: got %v, want ErrPRNotFound", err)
+	}
+
+	pr, err := s.repo.GetPullRequestByID(ctx, created.ID)
+	if err != nil {
+		return fmt.Errorf("get pr: %w", err)
+	}
+	if pr.Status != domain.StatusOpen || len(pr.AssignedReviewers) != 2 {
+		return fmt.Errorf("get pr: got status %s and reviewers %v", pr.Status, pr.AssignedReviewers)
+	}
+	// Ревьюеры возвращаются в порядке ID независимо от порядка назначения.
+	if want := []string{s.id("erin"), s.id("frank")}; !slices.Equal(pr.AssignedReviewers, want) {
+		return fmt.Errorf("get pr: got reviewers %v, want %v", pr.AssignedReviewers, want)
+	}
+	for _, reviewerID := range pr.AssignedReviewers {
+		if pr.ReviewStates[reviewerID] != domain.ReviewPending {
+			return fmt.Errorf("get pr: reviewer %s starts in %s, want PENDING", reviewerID, pr.ReviewStates[reviewerID])
+		}
+	}
+
+	loads, err := s.repo.CountOpenReviews(ctx, []string{s.id("erin"), s.id("dan")})
+	if err != nil {
+		return fmt.Errorf("count open reviews: %w", err)
+	}
+	if loads[s.id("erin")] != 1 || loads[s.id("dan")] != 0 {
+		return fmt.Errorf("count open reviews: got %v", loads)
+	}
+
+	page, err := s.repo.ListReviewerPullRequests(ctx, domain.ReviewerPRFilter{ReviewerID: s.id("erin")})
+	if err != nil {
+		return fmt.Errorf("prs by reviewer: %w", err)
+	}
+	if len(page.PullRequests) != 1 || page.PullRequests[0].ID != created.ID || page.Next != nil {
+		return fmt.Errorf("prs by reviewer: got %d prs", len(page.PullRequests))
+	}
+	if listed := page.PullRequests[0]; !slices.Equal(listed.AssignedReviewers, []string{s.id("erin"), s.id("frank")}) ||
+		listed.ReviewStates[s.id("frank")] != domain.ReviewPending || !listed.CreatedAt.Equal(pr.CreatedAt) {
+		return fmt.Errorf("prs by reviewer: got %+v, want %+v", listed, pr)
+	}
+
+	if err := s.repo.SetReviewState(ctx, created.ID, pr.Version, s.id("erin"), domain.ReviewApproved); err != nil {
+		return fmt.Errorf("set review state: %w", err)
+	}
+	page, err = s.repo.ListReviewerPullRequests(ctx, domain.ReviewerPRFilter{ReviewerID: s.id("erin"), PendingOnly: true})
+	if err != nil {
+		return fmt.Errorf("pending prs by reviewer: %w", err)
+	}
+	if len(page.PullRequests) != 0 {
+		return fmt.Errorf("pending prs by reviewer: got %d prs after approval, want 0", len(page.PullRequests))
+	}
+	return nil
+}
+
+func checkReviewerLists(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "lists", "ivy", "jon", "kim"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	// Пять PR с разницей в час; у двух одинаковое время, их порядок определяет ID.
+	// Часть времени задана в другом часовом поясе, чтобы хранилище сравнивало моменты, а не строки.
+	base := time.Now().Truncate(time.Second).Add(-24 * time.Hour)
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	created := []struct {
+		name, author string
+		at           time.Time
+		status       domain.PRStatus
+	}{
+		{"list-a", "jon", base, domain.StatusOpen},
+		{"list-b", "kim", base.Add(time.Hour).In(zone), domain.StatusOpen},
+		{"list-c", "jon", base.Add(2 * time.Hour), domain.StatusOpen},
+		{"list-d", "jon", base.Add(2 * time.Hour).In(zone), domain.StatusOpen},
+		{"list-e", "kim", base.Add(3 * time.Hour), domain.StatusMerged},
+	}
+	for _, c := range created {
+		_, err := s.repo.CreatePullRequest(ctx, domain.PullRequest{
+			ID: s.id(c.name), Name: c.name, AuthorID: s.id(c.author), TeamName: s.id("lists"), Status: c.status,
+			AssignedReviewers: []string{s.id("ivy")}, CreatedAt: c.at,
+		})
+		if err != nil {
+			return fmt.Errorf("create pr %s: %w", c.name, err)
+		}
+	}
+
+	// list возвращает ID выбранных PR без префикса, проходя по всем страницам.
+	list := func(filter domain.ReviewerPRFilter) ([]string, int, error) {
+		filter.ReviewerID = s.id("ivy")
+		var ids []string
+		pages := 0
+		for {
+			page, err := s.repo.ListReviewerPullRequests(ctx, filter)
+			if err != nil {
+				return nil, 0, err
+			}
+			pages++
+			for _, pr := range page.PullRequests {
+				ids = append(ids, pr.Name)
+			}
+			if page.Next == nil || pages > 10 {
+				return ids, pages, nil
+			}
+			filter.After = page.Next
+		}
+	}
+
+	from, to := base.Add(time.Hour), base.Add(2*time.Hour).In(zone)
+	cases := []struct {
+		name   string
+		filter domain.ReviewerPRFilter
+		want   []string
+		pages  int
+	}{
+		{"newest first", domain.ReviewerPRFilter{Sort: domain.SortCreatedDesc},
+			[]string{"list-d", "list-c", "list-b", "list-a"}, 1},
+		{"pages", domain.ReviewerPRFilter{Sort: domain.SortCreatedDesc, Limit: 2},
+			[]string{"list-d", "list-c", "list-b", "list-a"}, 2},
+		{"oldest first", domain.ReviewerPRFilter{Sort: domain.SortCreatedAsc, Limit: 3},
+			[]string{"list-a", "list-b", "list-c", "list-d"}, 2},
+		{"status", domain.ReviewerPRFilter{Statuses: []domain.PRStatus{domain.StatusMerged, domain.StatusOpen}, Limit: 4},
+			[]string{"list-e", "list-d", "list-c", "list-b", "list-a"}, 2},
+		{"author", domain.ReviewerPRFilter{AuthorID: s.id("jon"), Sort: domain.SortCreatedAsc},
+			[]string{"list-a", "list-c", "list-d"}, 1},
+		{"created range", domain.ReviewerPRFilter{CreatedFrom: &from, CreatedTo: &to, Sort: domain.SortCreatedAsc},
+			[]string{"list-b"}, 1},
+	}
+	for _, c := range cases {
+		ids, pages, err := list(c.filter)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+		if !slices.Equal(ids, c.want) || pages != c.pages {
+			return fmt.Errorf("%s: got %v in %d pages, want %v in %d", c.name, ids, pages, c.want, c.pages)
+		}
+	}
+	return nil
+}
+
+func checkPullRequestLists(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "search", "lena", "max", "nina"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	base := time.Now().Truncate(time.Second).Add(-24 * time.Hour)
+	created := []struct {
+		name, author string
+		reviewers    []string
+		at           time.Time
+	}{
+		{"Fix login", "lena", []string{"max"}, base},
+		{"Add LOGIN page", "max", []string{"nina"}, base.Add(time.Hour)},
+		{"Update docs", "lena", nil, base.Add(2 * time.Hour)},
+		{"Refactor", "nina", []string{"max", "lena"}, base.Add(3 * time.Hour)},
+	}
+	for _, c := range created {
+		reviewers := make([]string, len(c.reviewers))
+		for i, reviewer := range c.reviewers {
+			reviewers[i] = s.id(reviewer)
+		}
+		_, err := s.repo.CreatePullRequest(ctx, domain.PullRequest{
+			ID: s.id(c.name), Name: c.name, AuthorID: s.id(c.author), TeamName: s.id("search"),
+			Status: domain.StatusOpen, AssignedReviewers: reviewers, CreatedAt: c.at,
+		})
+		if err != nil {
+			return fmt.Errorf("create pr %s: %w", c.name, err)
+		}
+	}
+	merged, err := s.repo.GetPullRequestByID(ctx, s.id("Refactor"))
+	if err != nil {
+		return fmt.Errorf("get pr: %w", err)
+	}
+	if _, _, err := s.repo.MergePullRequest(ctx, merged.ID, merged.Version, false); err != nil {
+		return fmt.Errorf("merge pr: %w", err)
+	}
+
+	// list возвращает названия выбранных PR команды, проходя по всем страницам.
+	list := func(filter domain.PRFilter) ([]string, error) {
+		filter.TeamName = s.id("search")
+		var names []string
+		for pages := 0; pages < 10; pages++ {
+			page, err := s.repo.ListPullRequests(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, pr := range page.PullRequests {
+				if pr.AssignedReviewers == nil {
+					return nil, fmt.Errorf("pr %s has nil reviewers", pr.Name)
+				}
+				names = append(names, pr.Name)
+			}
+			if page.Next == nil {
+				break
+			}
+			filter.After = page.Next
+		}
+		return names, nil
+	}
+
+	now := time.Now()
+	mergedFrom, mergedTo := now.Add(-time.Hour), now.Add(time.Hour)
+	createdTo := base.Add(2 * time.Hour)
+	cases := []struct {
+		name   string
+		filter domain.PRFilter
+		want   []string
+	}{
+		{"all statuses", domain.PRFilter{Sort: domain.SortCreatedDesc, Limit: 3},
+			[]string{"Refactor", "Update docs", "Add LOGIN page", "Fix login"}},
+		{"status", domain.PRFilter{Statuses: []domain.PRStatus{domain.StatusOpen}, Sort: domain.SortCreatedAsc},
+			[]string{"Fix login", "Add LOGIN page", "Update docs"}},
+		{"author", domain.PRFilter{AuthorID: s.id("lena"), Sort: domain.SortCreatedAsc},
+			[]string{"Fix login", "Update docs"}},
+		{"reviewer", domain.PRFilter{ReviewerID: s.id("max"), Sort: domain.SortCreatedAsc, Limit: 1},
+			[]string{"Fix login", "Refactor"}},
+		{"name", domain.PRFilter{NameContains: "Login", Sort: domain.SortCreatedAsc},
+			[]string{"Fix login", "Add LOGIN page"}},
+		{"created range", domain.PRFilter{CreatedTo: &createdTo, Sort: domain.SortCreatedDesc},
+			[]string{"Add LOGIN page", "Fix login"}},
+		{"merged range", domain.PRFilter{MergedFrom: &mergedFrom, MergedTo: &mergedTo, Sort: domain.SortCreatedDesc},
+			[]string{"Refactor"}},
+		{"merged before", domain.PRFilter{MergedTo: &mergedFrom, Sort: domain.SortCreatedDesc},
+			nil},
+	}
+	for _, c := range cases {
+		names, err := list(c.filter)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+		if !slices.Equal(names, c.want) {
+			return fmt.Errorf("%s: got %v, want %v", c.name, names, c.want)
+		}
+	}
+
+	// Ревьюеры выбранных PR загружаются вместе с ними.
+	page, err := s.repo.ListPullRequests(ctx, domain.PRFilter{TeamName: s.id("search"), AuthorID: s.id("nina")})
+	if err != nil {
+		return fmt.Errorf("list by author: %w", err)
+	}
+	want := []string{s.id("lena"), s.id("max")}
+	if len(page.PullRequests) != 1 {
+		return fmt.Errorf("list by author: got %d prs, want 1", len(page.PullRequests))
+	}
+	if got := slices.Sorted(slices.Values(page.PullRequests[0].AssignedReviewers)); !slices.Equal(got, want) {
+		return fmt.Errorf("list by author: got reviewers %v, want %v", got, want)
+	}
+	return nil
+}
+
+func checkStats(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "stats", "olga", "pete", "quinn"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	// Первый PR создан сутки назад и слит сейчас, во втором pete заменяют на quinn.
+	created := time.Now().Add(-24 * time.Hour)
+	assigned := func(prID string, at time.Time, reviewers ...string) []domain.PREvent {
+		events := make([]domain.PREvent, len(reviewers))
+		for i, reviewer := range reviewers {
+			events[i] = domain.PREvent{
+				PullRequestID: prID, Type: domain.EventReviewerAssigned, ReviewerID: s.id(reviewer), CreatedAt: at,
+			}
+		}
+		return events
+	}
+	merged, err := s.repo.CreatePullRequest(ctx, domain.PullRequest{
+		ID: s.id("pr-stats-merged"), Name: "pr-stats-merged", AuthorID: s.id("olga"), TeamName: s.id("stats"),
+		Status: domain.StatusOpen, AssignedReviewers: []string{s.id("pete"), s.id("quinn")}, CreatedAt: created,
+	}, assigned(s.id("pr-stats-merged"), created, "pete", "quinn")...)
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+	if _, _, err := s.repo.MergePullRequest(ctx, merged.ID, merged.Version, false); err != nil {
+		return fmt.Errorf("merge pr: %w", err)
+	}
+	open, err := s.repo.CreatePullRequest(ctx, domain.PullRequest{
+		ID: s.id("pr-stats-open"), Name: "pr-stats-open", AuthorID: s.id("olga"), TeamName: s.id("stats"),
+		Status: domain.StatusOpen, AssignedReviewers: []string{s.id("pete")}, CreatedAt: created.Add(time.Hour),
+	}, assigned(s.id("pr-stats-open"), created.Add(time.Hour), "pete")...)
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+	replaced := domain.PREvent{
+		PullRequestID: open.ID, Type: domain.EventReviewerReplaced,
+		ReviewerID: s.id("quinn"), OldReviewerID: s.id("pete"), CreatedAt: time.Now(),
+	}
+	if err := s.repo.UpdatePullRequestReviewers(ctx, open.ID, open.Version, []string{s.id("quinn")}, replaced); err != nil {
+		return fmt.Errorf("replace reviewer: %w", err)
+	}
+
+	// aroundDay сообщает, что длительность задана и близка к суткам.
+	aroundDay := func(d *time.Duration) bool {
+		return d != nil && *d > 23*time.Hour && *d < 25*time.Hour
+	}
+
+	all := domain.StatsFilter{TeamName: s.id("stats")}
+	reviewers, err := s.repo.ReviewerStats(ctx, all)
+	if err != nil {
+		return fmt.Errorf("reviewer stats: %w", err)
+	}
+	if len(reviewers) != 2 || reviewers[0].UserID != s.id("pete") || reviewers[1].UserID != s.id("quinn") {
+		return fmt.Errorf("reviewer stats: got %+v, want pete and quinn", reviewers)
+	}
+	// Назначение pete на открытый PR учитывается, хотя его потом заменили.
+	pete, quinn := reviewers[0], reviewers[1]
+	if pete.Assignments != 2 || pete.OpenReviews != 0 || pete.ReassignedAway != 1 || !aroundDay(pete.MedianTimeToMerge) {
+		return fmt.Errorf("reviewer stats: got %+v for pete", pete)
+	}
+	if quinn.Assignments != 2 || quinn.OpenReviews != 1 || quinn.ReassignedAway != 0 || !aroundDay(quinn.MedianTimeToMerge) {
+		return fmt.Errorf("reviewer stats: got %+v for quinn", quinn)
+	}
+
+	// За последний час quinn назначен только на открытый PR, а у pete осталась лишь замена.
+	from := time.Now().Add(-time.Hour)
+	recent := domain.StatsFilter{TeamName: s.id("stats"), From: &from}
+	reviewers, err = s.repo.ReviewerStats(ctx, recent)
+	if err != nil {
+		return fmt.Errorf("recent reviewer stats: %w", err)
+	}
+	want := []domain.ReviewerStats{
+		{UserID: s.id("pete"), ReassignedAway: 1},
+		{UserID: s.id("quinn"), Assignments: 1, OpenReviews: 1},
+	}
+	if !slices.Equal(reviewers, want) {
+		return fmt.Errorf("recent reviewer stats: got %+v, want %+v", reviewers, want)
+	}
+
+	teams, err := s.repo.TeamStats(ctx, all)
+	if err != nil {
+		return fmt.Errorf("team stats: %w", err)
+	}
+	if len(teams) != 1 || teams[0].TeamName != s.id("stats") || teams[0].OpenPRs != 1 || teams[0].MergedPRs != 1 ||
+		!aroundDay(teams[0].AvgTimeToMerge) {
+		return fmt.Errorf("team stats: got %+v", teams)
+	}
+	teams, err = s.repo.TeamStats(ctx, recent)
+	if err != nil {
+		return fmt.Errorf("recent team stats: %w", err)
+	}
+	if wantTeams := []domain.TeamStats{{TeamName: s.id("stats")}}; !slices.Equal(teams, wantTeams) {
+		return fmt.Errorf("recent team stats: got %+v, want %+v", teams, wantTeams)
+	}
+	return nil
+}
+
+func checkVersions(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "versions", "gina", "hank", "ivan"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	pr, err := s.pr(ctx, "pr-versions", "gina", "hank")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+
+	if err := s.repo.UpdatePullRequestReviewers(ctx, pr.ID, pr.Version+1, []string{s.id("ivan")}); !errors.Is(err, app.ErrVersionConflict) {
+		return fmt.Errorf("stale reviewers update: got %v, want ErrVersionConflict", err)
+	}
+	if err := s.repo.UpdatePullRequestReviewers(ctx, s.id("pr-missing"), 1, nil); !errors.Is(err, app.ErrPRNotFound) {
+		return fmt.Errorf("reviewers update of missing pr: got %v, want ErrPRNotFound", err)
+	}
+
+	if err := s.repo.SetReviewState(ctx, pr.ID, pr.Version, s.id("ivan"), domain.ReviewApproved); !errors.Is(err, app.ErrReviewerNotAssigned) {
+		return fmt.Errorf("review by unassigned user: got %v, want ErrReviewerNotAssigned", err)
+	}
+	current, err := s.repo.GetPullRequestByID(ctx, pr.ID)
+	if err != nil {
+		return fmt.Errorf("get pr: %w", err)
+	}
+	if current.Version != pr.Version {
+		return fmt.Errorf("failed update changed version from %d to %d", pr.Version, current.Version)
+	}
+
+	if err := s.repo.SetReviewState(ctx, pr.ID, pr.Version, s.id("hank"), domain.ReviewApproved); err != nil {
+		return fmt.Errorf("set review state: %w", err)
+	}
+	if err := s.repo.UpdatePullRequestReviewers(ctx, pr.ID, pr.Version+1, []string{s.id("hank"), s.id("ivan")}); err != nil {
+		return fmt.Errorf("update reviewers: %w", err)
+	}
+
+	current, err = s.repo.GetPullRequestByID(ctx, pr.ID)
+	if err != nil {
+		return fmt.Errorf("get pr: %w", err)
+	}
+	if current.Version != pr.Version+2 {
+		return fmt.Errorf("got version %d after two updates, want %d", current.Version, pr.Version+2)
+	}
+	if current.ReviewStates[s.id("hank")] != domain.ReviewApproved || current.ReviewStates[s.id("ivan")] != domain.ReviewPending {
+		return fmt.Errorf("update reviewers: got review states %v", current.ReviewStates)
+	}
+	return nil
+}
+
+func checkMerge(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "merge", "jack", "kate"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	pr, err := s.pr(ctx, "pr-merge", "jack", "kate")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+
+	if _, _, err := s.repo.MergePullRequest(ctx, pr.ID, pr.Version+1, false); !errors.Is(err, app.ErrVersionConflict) {
+		return fmt.Errorf("stale merge: got %v, want ErrVersionConflict", err)
+	}
+
+	merged, mergedNow, err := s.repo.MergePullRequest(ctx, pr.ID, pr.Version, true)
+	if err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	if merged.Status != domain.StatusMerged || merged.MergedAt == nil || !merged.ForceMerged || !mergedNow {
+		return fmt.Errorf("merge: got status %s, merged_at %v, forced %t, merged now %t",
+			merged.Status, merged.MergedAt, merged.ForceMerged, mergedNow)
+	}
+
+	again, mergedNow, err := s.repo.MergePullRequest(ctx, pr.ID, pr.Version, false)
+	if err != nil {
+		return fmt.Errorf("repeated merge: %w", err)
+	}
+	if !again.MergedAt.Equal(*merged.MergedAt) || again.Version != merged.Version {
+		return errors.New("repeated merge changed the pull request")
+	}
+	if mergedNow {
+		return errors.New("repeated merge reported the pull request as merged by this call")
+	}
+
+	loads, err := s.repo.CountOpenReviews(ctx, []string{s.id("kate")})
+	if err != nil {
+		return fmt.Errorf("count open reviews: %w", err)
+	}
+	if loads[s.id("kate")] != 0 {
+		return fmt.Errorf("merged pr is still counted as open review: %v", loads)
+	}
+
+	if _, _, err := s.repo.MergePullRequest(ctx, s.id("pr-missing"), 1, false); !errors.Is(err, app.ErrPRNotFound) {
+		return fmt.Errorf("merge of missing pr: got %v, want ErrPRNotFound", err)
+	}
+	return nil
+}
+
+func checkStatus(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "status", "liam", "mia", "noah"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	pr, err := s.pr(ctx, "pr-status", "liam", "mia")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+
+	closed, err := s.repo.UpdatePullRequestStatus(ctx, pr.ID, pr.Version, domain.StatusClosed, []string{})
+	if err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	if closed.Status != domain.StatusClosed || closed.ClosedAt == nil || len(closed.AssignedReviewers) != 0 {
+		return fmt.Errorf("close: got status %s, closed_at %v, reviewers %v", closed.Status, closed.ClosedAt, closed.AssignedReviewers)
+	}
+
+	if _, err := s.repo.UpdatePullRequestStatus(ctx, pr.ID, pr.Version, domain.StatusOpen, nil); !errors.Is(err, app.ErrVersionConflict) {
+		return fmt.Errorf("stale status update: got %v, want ErrVersionConflict", err)
+	}
+
+	reopened, err := s.repo.UpdatePullRequestStatus(ctx, pr.ID, closed.Version, domain.StatusOpen, []string{s.id("noah")})
+	if err != nil {
+		return fmt.Errorf("reopen: %w", err)
+	}
+	if reopened.Status != domain.StatusOpen || reopened.ClosedAt != nil || reopened.ReviewStates[s.id("noah")] != domain.ReviewPending {
+		return fmt.Errorf("reopen: got status %s, closed_at %v, review states %v", reopened.Status, reopened.ClosedAt, reopened.ReviewStates)
+	}
+	return nil
+}
+
+func checkEvents(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "events", "olga", "paul"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	prID := s.id("pr-events")
+	event := func(eventType domain.PREventType) domain.PREvent {
+		return domain.PREvent{PullRequestID: prID, Type: eventType, ActorID: s.id("olga"), CreatedAt: time.Now()}
+	}
+	pr, err := s.repo.CreatePullRequest(ctx, domain.PullRequest{
+		ID: prID, Name: "events", AuthorID: s.id("olga"), Status: domain.StatusOpen,
+		AssignedReviewers: []string{s.id("paul")}, CreatedAt: time.Now(),
+	}, event(domain.EventCreated), event(domain.EventReviewerAssigned))
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+
+	// Неудачное изменение не должно оставлять событий в журнале.
+	if _, _, err := s.repo.MergePullRequest(ctx, prID, pr.Version+1, false, event(domain.EventMerged)); !errors.Is(err, app.ErrVersionConflict) {
+		return fmt.Errorf("stale merge: got %v, want ErrVersionConflict", err)
+	}
+	if _, _, err := s.repo.MergePullRequest(ctx, prID, pr.Version, false, event(domain.EventMerged)); err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	if _, _, err := s.repo.MergePullRequest(ctx, prID, pr.Version, false, event(domain.EventMerged)); err != nil {
+		return fmt.Errorf("repeated merge: %w", err)
+	}
+
+	events, err := s.repo.GetPullRequestEvents(ctx, prID)
+	if err != nil {
+		return fmt.Errorf("get events: %w", err)
+	}
+	want := []domain.PREventType{domain.EventCreated, domain.EventReviewerAssigned, domain.EventMerged}
+	if len(events) != len(want) {
+		return fmt.Errorf("got %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.Type != want[i] || e.ActorID != s.id("olga") {
+			return fmt.Errorf("event %d: got %s by %q, want %s", i, e.Type, e.ActorID, want[i])
+		}
+		if i > 0 && e.ID <= events[i-1].ID {
+			return fmt.Errorf("event ids are not increasing: %d after %d", e.ID, events[i-1].ID)
+		}
+	}
+	return nil
+}
+
+func checkIdempotency(ctx context.Context, s *suite) error {
+	key := s.id("key")
+	// Ключи, занятые в пределах последнего часа, не считаются устаревшими.
+	hourAgo := time.Now().Add(-time.Hour)
+
+	if _, reserved, err := s.repo.ReserveIdempotencyKey(ctx, key, "hash", hourAgo, hourAgo); err != nil || !reserved {
+		return fmt.Errorf("reserve: got reserved=%t, err %v", reserved, err)
+	}
+
+	record, reserved, err := s.repo.ReserveIdempotencyKey(ctx, key, "other", hourAgo, hourAgo)
+	if err != nil || reserved {
+		return fmt.Errorf("reserve taken key: got reserved=%t, err %v", reserved, err)
+	}
+	if record.RequestHash != "hash" || record.Completed() {
+		return fmt.Errorf("reserve taken key: got %+v", record)
+	}
+
+	response := domain.IdempotencyRecord{
+		Key: key, StatusCode: 201, ContentType: "application/json", ETag: `"3"`, Body: []byte(`{}`),
+	}
+	if err := s.repo.SaveIdempotencyResponse(ctx, response); err != nil {
+		return fmt.Errorf("save response: %w", err)
+	}
+	record, _, err = s.repo.ReserveIdempotencyKey(ctx, key, "hash", hourAgo, hourAgo)
+	if err != nil {
+		return fmt.Errorf("reserve completed key: %w", err)
+	}
+	if record.StatusCode != 201 || record.ContentType != response.ContentType || record.ETag != response.ETag ||
+		string(record.Body) != `{}` {
+		return fmt.Errorf("reserve completed key: got %+v", record)
+	}
+
+	// Ответ уже сохранен, поэтому ключ не считается брошенным, пока не истек срок хранения.
+	inHour := time.Now().Add(time.Hour)
+	if _, reserved, err := s.repo.ReserveIdempotencyKey(ctx, key, "other", inHour, hourAgo); err != nil || reserved {
+		return fmt.Errorf("reserve completed key after reservation ttl: got reserved=%t, err %v", reserved, err)
+	}
+	if _, reserved, err := s.repo.ReserveIdempotencyKey(ctx, key, "other", hourAgo, inHour); err != nil || !reserved {
+		return fmt.Errorf("reserve expired key: got reserved=%t, err %v", reserved, err)
+	}
+	record, _, err = s.repo.ReserveIdempotencyKey(ctx, key, "hash", hourAgo, hourAgo)
+	if err != nil {
+		return fmt.Errorf("reserve renewed key: %w", err)
+	}
+	if record.RequestHash != "other" || record.Completed() || record.ContentType != "" || record.ETag != "" {
+		return fmt.Errorf("reserve renewed key: got %+v", record)
+	}
+
+	// Ключ без ответа перехватывается после срока резервирования.
+	if _, reserved, err := s.repo.ReserveIdempotencyKey(ctx, key, "hash", inHour, hourAgo); err != nil || !reserved {
+		return fmt.Errorf("reserve stale key: got reserved=%t, err %v", reserved, err)
+	}
+
+	if err := s.repo.ReleaseIdempotencyKey(ctx, key); err != nil {
+		return fmt.Errorf("release: %w", err)
+	}
+	if _, reserved, err := s.repo.ReserveIdempotencyKey(ctx, key, "hash", hourAgo, hourAgo); err != nil || !reserved {
+		return fmt.Errorf("reserve released key: got reserved=%t, err %v", reserved, err)
+	}
+
+	if _, err := s.repo.DeleteExpiredIdempotencyKeys(ctx, hourAgo); err != nil {
+		return fmt.Errorf("delete expired keys: %w", err)
+	}
+	if _, reserved, err := s.repo.ReserveIdempotencyKey(ctx, key, "other", hourAgo, hourAgo); err != nil || reserved {
+		return fmt.Errorf("reserve key kept by cleanup: got reserved=%t, err %v", reserved, err)
+	}
+	deleted, err := s.repo.DeleteExpiredIdempotencyKeys(ctx, inHour)
+	if err != nil || deleted < 1 {
+		return fmt.Errorf("delete expired keys: got %d deleted, err %v", deleted, err)
+	}
+	if _, reserved, err := s.repo.ReserveIdempotencyKey(ctx, key, "hash", hourAgo, hourAgo); err != nil || !reserved {
+		return fmt.Errorf("reserve deleted key: got reserved=%t, err %v", reserved, err)
+	}
+	return nil
+}
+
+// checkConcurrentUpdates проверяет, что из нескольких изменений одной версии PR проходит ровно одно.
+func checkConcurrentUpdates(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "concurrent", "quinn", "rosa"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	pr, err := s.pr(ctx, "pr-concurrent", "quinn", "rosa")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan error, concurrentWriters)
+	for i := 0; i < concurrentWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- s.repo.SetReviewState(ctx, pr.ID, pr.Version, s.id("rosa"), domain.ReviewApproved)
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	succeeded := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, app.ErrVersionConflict):
+			return fmt.Errorf("concurrent review: %w", err)
+		}
+	}
+	if succeeded != 1 {
+		return fmt.Errorf("got %d successful updates of version %d, want 1", succeeded, pr.Version)
+	}
+
+	// Слияние одного PR параллельными запросами засчитывается ровно одному из них.
+	merging, err := s.pr(ctx, "pr-concurrent-merge", "quinn", "rosa")
+	if err != nil {
+		return fmt.Errorf("create pr: %w", err)
+	}
+	merges := make(chan error, concurrentWriters)
+	var mergedNow atomic.Int32
+	for i := 0; i < concurrentWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, merged, err := s.repo.MergePullRequest(ctx, merging.ID, merging.Version, false)
+			if merged {
+				mergedNow.Add(1)
+			}
+			merges <- err
+		}()
+	}
+	wg.Wait()
+	close(merges)
+
+	for err := range merges {
+		if err != nil {
+			return fmt.Errorf("concurrent merge: %w", err)
+		}
+	}
+	if got := mergedNow.Load(); got != 1 {
+		return fmt.Errorf("got %d calls that merged the pull request, want 1", got)
+	}
+	return nil
+}
+
+func checkAPITokens(ctx context.Context, s *suite) error {
+	if _, err := s.team(ctx, "tokens", "tom"); err != nil {
+		return fmt.Errorf("create team: %w", err)
+	}
+	hash := func(name string) string {
+		sum := sha256.Sum256([]byte(s.id(name)))
+		return hex.EncodeToString(sum[:])
+	}
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	member, err := s.repo.CreateAPIToken(ctx, domain.APIToken{
+		Name: "tom laptop", Role: domain.RoleMember, UserID: s.id("tom"), ExpiresAt: &expiresAt,
+	}, hash("member"))
+	if err != nil {
+		return fmt.Errorf("create token: %w", err)
+	}
+	if member.ID == 0 || member.CreatedAt.IsZero() {
+		return fmt.Errorf("create token: got %+v", member)
+	}
+	bot, err := s.repo.CreateAPIToken(ctx, domain.APIToken{Name: "ci", Role: domain.RoleBot}, hash("bot"))
+	if err != nil {
+		return fmt.Errorf("create token without user: %w", err)
+	}
+	if _, err := s.repo.CreateAPIToken(ctx, domain.APIToken{
+		Name: "ghost", Role: domain.RoleMember, UserID: s.id("nobody"),
+	}, hash("ghost")); !errors.Is(err, app.ErrUserNotFound) {
+		return fmt.Errorf("token of missing user: got %v, want ErrUserNotFound", err)
+	}
+
+	stored, err := s.repo.GetAPITokenByHash(ctx, hash("member"))
+	if err != nil {
+		return fmt.Errorf("get token by hash: %w", err)
+	}
+	if stored.ID != member.ID || stored.Name != "tom laptop" || stored.Role != domain.RoleMember ||
+		stored.UserID != s.id("tom") || stored.ExpiresAt == nil || !stored.ExpiresAt.Equal(expiresAt) ||
+		stored.RevokedAt != nil || !stored.Active(time.Now()) {
+		return fmt.Errorf("get token by hash: got %+v", stored)
+	}
+	if stored, err := s.repo.GetAPIToken(ctx, bot.ID); err != nil || stored.UserID != "" || stored.ExpiresAt != nil {
+		return fmt.Errorf("get token: got %+v, %v", stored, err)
+	}
+	if _, err := s.repo.GetAPITokenByHash(ctx, hash("missing")); !errors.Is(err, app.ErrTokenNotFound) {
+		return fmt.Errorf("get missing token: got %v, want ErrTokenNotFound", err)
+	}
+
+	tokens, err := s.repo.ListAPITokens(ctx, s.id("tom"))
+	if err != nil {
+		return fmt.Errorf("list tokens: %w", err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != member.ID {
+		return fmt.Errorf("list user tokens: got %+v", tokens)
+	}
+	if tokens, err = s.repo.ListAPITokens(ctx, ""); err != nil || len(tokens) < 2 {
+		return fmt.Errorf("list all tokens: got %d tokens, %v", len(tokens), err)
+	}
+
+	revokedAt := time.Now().Truncate(time.Second)
+	revoked, err := s.repo.RevokeAPIToken(ctx, member.ID, revokedAt)
+	if err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+	if revoked.RevokedAt == nil || !revoked.RevokedAt.Equal(revokedAt) || revoked.Active(time.Now()) {
+		return fmt.Errorf("revoke token: got %+v", revoked)
+	}
+	// Повторный отзыв не переносит время отзыва.
+	if again, err := s.repo.RevokeAPIToken(ctx, member.ID, revokedAt.Add(time.Hour)); err != nil ||
+		again.RevokedAt == nil || !again.RevokedAt.Equal(revokedAt) {
+		return fmt.Errorf("revoke revoked token: got %+v, %v", again, err)
+	}
+	if _, err := s.repo.RevokeAPIToken(ctx, -1, revokedAt); !errors.Is(err, app.ErrTokenNotFound) {
+		return fmt.Errorf("revoke missing token: got %v, want ErrTokenNotFound", err)
+	}
+	return nil
+}
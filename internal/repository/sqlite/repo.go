@@ -0,0 +1,1454 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/repository"
+)
+
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) repository.Repository {
+	return &SQLiteRepository{db: db}
+}
+
+// userTeamsColumn выбирает JSON-массив команд пользователя u в порядке имени.
+const userTeamsColumn = `(SELECT json_group_array(team_name)
+	FROM (SELECT team_name FROM team_members WHERE user_id = u.user_id ORDER BY team_name))`
+
+// querier - общие методы *sql.DB и *sql.Tx для чтения внутри транзакции и вне её.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (r *SQLiteRepository) CreateTeam(ctx context.Context, team domain.Team) (domain.Team, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Team{}, err
+	}
+	defer tx.Rollback()
+
+	// Создаем запись о команде.
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO teams (team_name, reviewer_strategy, min_reviewers, max_reviewers, merge_policy, required_approvals)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		team.Name, team.ReviewerStrategy, team.MinReviewers, team.MaxReviewers, team.MergePolicy, team.RequiredApprovals)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.Team{}, &app.ErrTeamExists{TeamName: team.Name}
+		}
+		return domain.Team{}, err
+	}
+
+	// Добавляем участников; пользователи из других команд остаются и в них.
+	for _, member := range team.Members {
+		if err := addMember(ctx, tx, team.Name, member); err != nil {
+			return domain.Team{}, err
+		}
+	}
+	if err := replaceReviewerPools(ctx, tx, team.Name, team.ReviewerPools); err != nil {
+		return domain.Team{}, err
+	}
+	if err := replaceCodeOwners(ctx, tx, team.Name, team.CodeOwners); err != nil {
+		return domain.Team{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Team{}, err
+	}
+	return team, nil
+}
+
+func (r *SQLiteRepository) GetTeamByName(ctx context.Context, teamName string) (domain.Team, error) {
+	team := domain.Team{Name: teamName}
+
+	// Получаем настройки команды, заодно проверяя её существование.
+	err := r.db.QueryRowContext(ctx,
+		`SELECT reviewer_strategy, min_reviewers, max_reviewers, merge_policy, required_approvals
+		 FROM teams WHERE team_name = ?`, teamName,
+	).Scan(&team.ReviewerStrategy, &team.MinReviewers, &team.MaxReviewers, &team.MergePolicy, &team.RequiredApprovals)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Team{}, app.ErrNotFound
+		}
+		return domain.Team{}, err
+	}
+
+	// Находим всех участников указанной команды вместе с их остальными командами.
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT u.user_id, u.username, u.is_active, u.review_weight, COALESCE(u.team_name, ''), `+userTeamsColumn+`
+		 FROM team_members m JOIN users u ON u.user_id = m.user_id
+		 WHERE m.team_name = ?`, teamName)
+	if err != nil {
+		return domain.Team{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user domain.User
+		var teams string
+		if err := rows.Scan(&user.ID, &user.Username, &user.IsActive, &user.ReviewWeight, &user.TeamName, &teams); err != nil {
+			return domain.Team{}, err
+		}
+		if err := json.Unmarshal([]byte(teams), &user.Teams); err != nil {
+			return domain.Team{}, err
+		}
+		team.Members = append(team.Members, user)
+	}
+	if rows.Err() != nil {
+		return domain.Team{}, rows.Err()
+	}
+
+	// Пулы ревьюеров в порядке их задания.
+	poolRows, err := r.db.QueryContext(ctx,
+		`SELECT p.mode, COALESCE(p.source_team, ''),
+		        (SELECT json_group_array(user_id)
+		         FROM (SELECT user_id FROM reviewer_pool_users WHERE pool_id = p.id ORDER BY user_id))
+		 FROM reviewer_pools p WHERE p.team_name = ? ORDER BY p.id`, teamName)
+	if err != nil {
+		return domain.Team{}, err
+	}
+	defer poolRows.Close()
+
+	team.ReviewerPools = []domain.ReviewerPool{}
+	for poolRows.Next() {
+		var pool domain.ReviewerPool
+		var userIDs string
+		if err := poolRows.Scan(&pool.Mode, &pool.TeamName, &userIDs); err != nil {
+			return domain.Team{}, err
+		}
+		if err := json.Unmarshal([]byte(userIDs), &pool.UserIDs); err != nil {
+			return domain.Team{}, err
+		}
+		team.ReviewerPools = append(team.ReviewerPools, pool)
+	}
+	if poolRows.Err() != nil {
+		return domain.Team{}, poolRows.Err()
+	}
+
+	// Правила владения кодом в порядке их задания.
+	ruleRows, err := r.db.QueryContext(ctx,
+		`SELECT c.pattern,
+		        (SELECT json_group_array(user_id)
+		         FROM (SELECT user_id FROM code_owner_rule_users WHERE rule_id = c.id ORDER BY user_id))
+		 FROM code_owner_rules c WHERE c.team_name = ? ORDER BY c.id`, teamName)
+	if err != nil {
+		return domain.Team{}, err
+	}
+	defer ruleRows.Close()
+
+	team.CodeOwners = []domain.CodeOwnerRule{}
+	for ruleRows.Next() {
+		var rule domain.CodeOwnerRule
+		var owners string
+		if err := ruleRows.Scan(&rule.Pattern, &owners); err != nil {
+			return domain.Team{}, err
+		}
+		if err := json.Unmarshal([]byte(owners), &rule.Owners); err != nil {
+			return domain.Team{}, err
+		}
+		team.CodeOwners = append(team.CodeOwners, rule)
+	}
+	if ruleRows.Err() != nil {
+		return domain.Team{}, ruleRows.Err()
+	}
+
+	return team, nil
+}
+
+func (r *SQLiteRepository) UpdateTeamSettings(ctx context.Context, teamName string, settings domain.TeamSettings) (domain.TeamSettings, error) {
+	// Сохраняем настройки и возвращаем то, что записалось в базу.
+	var updated domain.TeamSettings
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE teams SET reviewer_strategy = ?, min_reviewers = ?, max_reviewers = ?,
+		                  merge_policy = ?, required_approvals = ?
+		 WHERE team_name = ?
+		 RETURNING reviewer_strategy, min_reviewers, max_reviewers, merge_policy, required_approvals`,
+		settings.ReviewerStrategy, settings.MinReviewers, settings.MaxReviewers,
+		settings.MergePolicy, settings.RequiredApprovals, teamName,
+	).Scan(&updated.ReviewerStrategy, &updated.MinReviewers, &updated.MaxReviewers,
+		&updated.MergePolicy, &updated.RequiredApprovals)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.TeamSettings{}, app.ErrNotFound
+		}
+		return domain.TeamSettings{}, err
+	}
+	return updated, nil
+}
+
+func (r *SQLiteRepository) SetReviewerPools(ctx context.Context, teamName string, pools []domain.ReviewerPool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = ?)`, teamName).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return app.ErrNotFound
+	}
+
+	if err := replaceReviewerPools(ctx, tx, teamName, pools); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) SetCodeOwners(ctx context.Context, teamName string, rules []domain.CodeOwnerRule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = ?)`, teamName).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return app.ErrNotFound
+	}
+
+	if err := replaceCodeOwners(ctx, tx, teamName, rules); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) AddTeamMember(ctx context.Context, teamName string, member domain.User) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := addMember(ctx, tx, teamName, member); err != nil {
+		// Отсутствие команды нарушает внешний ключ.
+		if isForeignKeyViolation(err) {
+			return app.ErrNotFound
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) RemoveTeamMember(ctx context.Context, teamName, userID string, updates []domain.ReviewerUpdate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM team_members WHERE team_name = ? AND user_id = ?`, teamName, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// Ничего не удалили: либо нет команды, либо пользователь в ней не состоит.
+		var exists bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = ?)`, teamName).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return app.ErrNotFound
+		}
+		return app.ErrNotTeamMember
+	}
+
+	// Если команда была основной, основной становится первая из оставшихся.
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET team_name = (SELECT MIN(team_name) FROM team_members WHERE user_id = ?)
+		 WHERE user_id = ? AND team_name = ?`,
+		userID, userID, teamName)
+	if err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		if err := updateReviewers(ctx, tx, update.PullRequestID, update.Version, update.Reviewers, update.Events); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) RenameTeam(ctx context.Context, teamName, newName string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// SQLite не умеет менять внешний ключ на ON UPDATE CASCADE без пересоздания таблицы,
+	// поэтому копируем команду под новым именем, переносим ссылки на неё и удаляем старую запись.
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO teams (team_name, reviewer_strategy, min_reviewers, max_reviewers, merge_policy, required_approvals)
+		 SELECT ?, reviewer_strategy, min_reviewers, max_reviewers, merge_policy, required_approvals
+		 FROM teams WHERE team_name = ?`,
+		newName, teamName)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return &app.ErrTeamExists{TeamName: newName}
+		}
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return app.ErrNotFound
+	}
+
+	for _, query := range []string{
+		`UPDATE users SET team_name = ? WHERE team_name = ?`,
+		`UPDATE team_members SET team_name = ? WHERE team_name = ?`,
+		`UPDATE pull_requests SET team_name = ? WHERE team_name = ?`,
+		`UPDATE reviewer_pools SET team_name = ? WHERE team_name = ?`,
+		`UPDATE reviewer_pools SET source_team = ? WHERE source_team = ?`,
+		`UPDATE code_owner_rules SET team_name = ? WHERE team_name = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, newName, teamName); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM teams WHERE team_name = ?`, teamName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) DeleteTeam(ctx context.Context, teamName string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = ?)`, teamName).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return app.ErrNotFound
+	}
+
+	// Без команды незавершенным PR не из кого выбирать ревьюеров, поэтому они блокируют удаление.
+	var hasOpen bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM pull_requests WHERE team_name = ? AND status IN ('OPEN', 'DRAFT'))`,
+		teamName).Scan(&hasOpen)
+	if err != nil {
+		return err
+	}
+	if hasOpen {
+		return app.ErrTeamHasOpenPRs
+	}
+
+	// Членство удаляется каскадно, ссылки пользователей и PR на команду обнуляются.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM teams WHERE team_name = ?`, teamName); err != nil {
+		return err
+	}
+
+	// Участникам, для которых команда была основной, назначаем основной первую из оставшихся.
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET team_name = (SELECT MIN(m.team_name) FROM team_members m WHERE m.user_id = users.user_id)
+		 WHERE team_name IS NULL AND EXISTS(SELECT 1 FROM team_members m WHERE m.user_id = users.user_id)`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) SetUserActivity(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
+	// Обновляем статус пользователя и возвращаем обновленную запись.
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET is_active = ? WHERE user_id = ?`, isActive, userID)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, app.ErrUserNotFound
+	}
+	return r.GetUserByID(ctx, userID)
+}
+
+func (r *SQLiteRepository) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
+	user := &domain.User{}
+
+	// Находим пользователя по его ID вместе со всеми его командами.
+	var teams string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT u.user_id, u.username, u.is_active, COALESCE(u.team_name, ''), u.review_weight, `+userTeamsColumn+`
+		 FROM users u WHERE u.user_id = ?`,
+		userID,
+	).Scan(&user.ID, &user.Username, &user.IsActive, &user.TeamName, &user.ReviewWeight, &teams)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, app.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(teams), &user.Teams); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *SQLiteRepository) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(userIDs))
+	for _, id := range userIDs {
+		counts[id] = 0
+	}
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	// Считаем открытые PR, назначенные каждому из пользователей, одним запросом.
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT r.reviewer_id, COUNT(*)
+		 FROM pr_reviewers r
+		 JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		 WHERE r.reviewer_id IN (`+placeholders(len(userIDs))+`) AND pr.status = 'OPEN'
+		 GROUP BY r.reviewer_id`,
+		stringArgs(userIDs)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+		counts[userID] = count
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return counts, nil
+}
+
+func (r *SQLiteRepository) DeactivateUsers(ctx context.Context, userIDs []string, updates []domain.ReviewerUpdate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE users SET is_active = FALSE WHERE user_id IN (`+placeholders(len(userIDs))+`)`, stringArgs(userIDs)...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	// Повторяющийся идентификатор обновляет строку один раз.
+	distinct := len(slices.Compact(slices.Sorted(slices.Values(userIDs))))
+	if affected != int64(distinct) {
+		return app.ErrUserNotFound
+	}
+
+	for _, update := range updates {
+		if err := updateReviewers(ctx, tx, update.PullRequestID, update.Version, update.Reviewers, update.Events); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) CreateAbsence(ctx context.Context, absence domain.Absence) (domain.Absence, error) {
+	// Время храним в UTC: драйвер записывает time.Time строкой, и только
+	// в одном часовом поясе строки упорядочены хронологически.
+	absence.StartsAt, absence.EndsAt = absence.StartsAt.UTC(), absence.EndsAt.UTC()
+	absence.CreatedAt = time.Now().UTC()
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_absences (user_id, starts_at, ends_at, reason, created_at) VALUES (?, ?, ?, ?, ?)`,
+		absence.UserID, absence.StartsAt, absence.EndsAt, absence.Reason, absence.CreatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return domain.Absence{}, app.ErrUserNotFound
+		}
+		return domain.Absence{}, err
+	}
+	if absence.ID, err = res.LastInsertId(); err != nil {
+		return domain.Absence{}, err
+	}
+	return absence, nil
+}
+
+func (r *SQLiteRepository) GetAbsences(ctx context.Context, userID string) ([]domain.Absence, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT absence_id, user_id, starts_at, ends_at, reason, created_at
+		 FROM user_absences WHERE user_id = ? ORDER BY starts_at, absence_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	absences := make([]domain.Absence, 0)
+	for rows.Next() {
+		var a domain.Absence
+		if err := rows.Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		absences = append(absences, a)
+	}
+	return absences, rows.Err()
+}
+
+func (r *SQLiteRepository) DeleteAbsence(ctx context.Context, absenceID int64) (domain.Absence, error) {
+	var a domain.Absence
+	err := r.db.QueryRowContext(ctx,
+		`DELETE FROM user_absences WHERE absence_id = ?
+		 RETURNING absence_id, user_id, starts_at, ends_at, reason, created_at`, absenceID,
+	).Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Absence{}, app.ErrAbsenceNotFound
+		}
+		return domain.Absence{}, err
+	}
+	return a, nil
+}
+
+func (r *SQLiteRepository) GetAbsence(ctx context.Context, absenceID int64) (domain.Absence, error) {
+	var a domain.Absence
+	err := r.db.QueryRowContext(ctx,
+		`SELECT absence_id, user_id, starts_at, ends_at, reason, created_at
+		 FROM user_absences WHERE absence_id = ?`, absenceID,
+	).Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Absence{}, app.ErrAbsenceNotFound
+		}
+		return domain.Absence{}, err
+	}
+	return a, nil
+}
+
+func (r *SQLiteRepository) AbsentUsers(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error) {
+	absent := make(map[string]bool)
+	if len(userIDs) == 0 {
+		return absent, nil
+	}
+
+	at = at.UTC() // Сравниваем в том же поясе, в котором сохраняли
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT DISTINCT user_id FROM user_absences
+		 WHERE user_id IN (`+placeholders(len(userIDs))+`) AND starts_at <= ? AND ends_at > ?`,
+		append(stringArgs(userIDs), at, at)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		absent[userID] = true
+	}
+	return absent, rows.Err()
+}
+
+func (r *SQLiteRepository) CreatePullRequest(ctx context.Context, pr domain.PullRequest, events ...domain.PREvent) (*domain.PullRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Создаем основную запись о Pull Request в первой версии.
+	pr.Version = 1
+	if pr.ChangedFiles == nil {
+		pr.ChangedFiles = []string{}
+	}
+	changedFiles, err := json.Marshal(pr.ChangedFiles)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, team_name, status, changed_files,
+                                    required_reviewers, created_at)
+         VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?)`,
+		pr.ID, pr.Name, pr.AuthorID, pr.TeamName, pr.Status, string(changedFiles), pr.RequiredReviewers, pr.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, app.ErrPRExists
+		}
+		return nil, err
+	}
+
+	// Привязываем ревьюеров к созданному PR, их ревью начинается в состоянии PENDING.
+	pr.ReviewStates = make(map[string]domain.ReviewState, len(pr.AssignedReviewers))
+	for _, reviewerID := range pr.AssignedReviewers {
+		pr.ReviewStates[reviewerID] = domain.ReviewPending
+		_, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at) VALUES (?, ?, ?)`,
+			pr.ID, reviewerID, pr.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertEvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (r *SQLiteRepository) GetPullRequestByID(ctx context.Context, prID string) (*domain.PullRequest, error) {
+	return getPullRequest(ctx, r.db, prID)
+}
+
+func (r *SQLiteRepository) MergePullRequest(ctx context.Context, prID string, version int, forced bool, events ...domain.PREvent) (*domain.PullRequest, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// Сливаем PR одним условным UPDATE: транзакции SQLite пишут по очереди,
+	// поэтому merged_at выставляется ровно один раз.
+	res, err := tx.ExecContext(ctx,
+		`UPDATE pull_requests SET status = ?, merged_at = ?, force_merged = ?, version = version + 1
+		 WHERE pull_request_id = ? AND status = ? AND version = ?`,
+		domain.StatusMerged, time.Now(), forced, prID, domain.StatusOpen, version)
+	if err != nil {
+		return nil, false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// События пишем только при фактическом слиянии, повторный запрос журнал не меняет.
+	if affected == 1 {
+		if err := insertEvents(ctx, tx, events); err != nil {
+			return nil, false, err
+		}
+	}
+
+	pr, err := getPullRequest(ctx, tx, prID)
+	if err != nil {
+		return nil, false, err
+	}
+	// Ничего не обновили: PR уже слит (повторный запрос), изменен параллельно или находится в другом статусе.
+	if affected == 0 {
+		switch pr.Status {
+		case domain.StatusMerged:
+		case domain.StatusOpen:
+			return nil, false, app.ErrVersionConflict
+		default:
+			return nil, false, &app.ErrInvalidTransition{From: pr.Status, To: domain.StatusMerged}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return pr, affected == 1, nil
+}
+
+func (r *SQLiteRepository) UpdatePullRequestStatus(ctx context.Context, prID string, version int, to domain.PRStatus, reviewers []string, events ...domain.PREvent) (*domain.PullRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Меняем статус, только если PR не изменился с момента чтения.
+	var closedAt *time.Time
+	if to == domain.StatusClosed {
+		now := time.Now()
+		closedAt = &now
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE pull_requests SET status = ?, closed_at = ?, version = version + 1
+		 WHERE pull_request_id = ? AND version = ?`,
+		to, closedAt, prID, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAffected(ctx, tx, res, prID); err != nil {
+		return nil, err
+	}
+
+	// Полностью заменяем ревьюеров: их ревью начинаются заново.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE pr_id = ?`, prID); err != nil {
+		return nil, err
+	}
+	for _, reviewerID := range reviewers {
+		_, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at) VALUES (?, ?, ?)`,
+			prID, reviewerID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertEvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
+
+	pr, err := getPullRequest(ctx, tx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+func (r *SQLiteRepository) UpdatePullRequestReviewers(ctx context.Context, prID string, version int, reviewers []string, events ...domain.PREvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateReviewers(ctx, tx, prID, version, reviewers, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) SetReviewState(ctx context.Context, prID string, version int, reviewerID string, state domain.ReviewState, events ...domain.PREvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := bumpVersion(ctx, tx, prID, version); err != nil {
+		return err
+	}
+
+	// Обновляем решение ревьюера и время его принятия.
+	res, err := tx.ExecContext(ctx,
+		`UPDATE pr_reviewers SET review_state = ?, reviewed_at = ? WHERE pr_id = ? AND reviewer_id = ?`,
+		state, time.Now(), prID, reviewerID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return app.ErrReviewerNotAssigned
+	}
+
+	if err := insertEvents(ctx, tx, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) ListPullRequests(ctx context.Context, filter domain.PRFilter) (domain.PRPage, error) {
+	// Время в строках может быть записано с разными смещениями, поэтому сравниваем его через julianday.
+	q := &listQuery{}
+	if filter.AuthorID != "" {
+		q.add("pr.author_id = ?", filter.AuthorID)
+	}
+	if filter.TeamName != "" {
+		q.add("pr.team_name = ?", filter.TeamName)
+	}
+	if len(filter.Statuses) > 0 {
+		q.add("pr.status IN ("+placeholders(len(filter.Statuses))+")", statusArgs(filter.Statuses)...)
+	}
+	if filter.ReviewerID != "" {
+		q.add("EXISTS (SELECT 1 FROM pr_reviewers f WHERE f.pr_id = pr.pull_request_id AND f.reviewer_id = ?)",
+			filter.ReviewerID)
+	}
+	if filter.NameContains != "" {
+		q.add("instr(lower(pr.pull_request_name), lower(?)) > 0", filter.NameContains)
+	}
+	if filter.CreatedFrom != nil {
+		q.add("julianday(pr.created_at) >= julianday(?)", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q.add("julianday(pr.created_at) < julianday(?)", *filter.CreatedTo)
+	}
+	if filter.MergedFrom != nil {
+		q.add("julianday(pr.merged_at) >= julianday(?)", *filter.MergedFrom)
+	}
+	if filter.MergedTo != nil {
+		q.add("julianday(pr.merged_at) < julianday(?)", *filter.MergedTo)
+	}
+	return r.listPullRequests(ctx, q, filter.Sort, filter.After, filter.Limit)
+}
+
+func (r *SQLiteRepository) ListReviewerPullRequests(ctx context.Context, filter domain.ReviewerPRFilter) (domain.PRPage, error) {
+	q := &listQuery{}
+	reviewer := "EXISTS (SELECT 1 FROM pr_reviewers f WHERE f.pr_id = pr.pull_request_id AND f.reviewer_id = ?"
+	if filter.PendingOnly {
+		reviewer += " AND f.review_state = 'PENDING'"
+	}
+	q.add(reviewer+")", filter.ReviewerID)
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []domain.PRStatus{domain.StatusOpen}
+	}
+	q.add("pr.status IN ("+placeholders(len(statuses))+")", statusArgs(statuses)...)
+	if filter.AuthorID != "" {
+		q.add("pr.author_id = ?", filter.AuthorID)
+	}
+	if filter.CreatedFrom != nil {
+		q.add("julianday(pr.created_at) >= julianday(?)", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q.add("julianday(pr.created_at) < julianday(?)", *filter.CreatedTo)
+	}
+	return r.listPullRequests(ctx, q, filter.Sort, filter.After, filter.Limit)
+}
+
+// listPullRequests выбирает страницу PR по условиям q в порядке sort, начиная сразу после after.
+// Ревьюеры собираются тем же запросом в JSON-массив пар [ID, состояние], без отдельного чтения каждого PR.
+func (r *SQLiteRepository) listPullRequests(ctx context.Context, q *listQuery, sort domain.PRSort, after *domain.PRCursor, limit int) (domain.PRPage, error) {
+	order, next := "DESC", "<"
+	if sort == domain.SortCreatedAsc {
+		order, next = "ASC", ">"
+	}
+	if after != nil {
+		q.add("(julianday(pr.created_at), pr.pull_request_id) "+next+" (julianday(?), ?)", after.CreatedAt, after.ID)
+	}
+	where := "TRUE"
+	if len(q.conds) > 0 {
+		where = strings.Join(q.conds, " AND ")
+	}
+	// LIMIT -1 снимает ограничение; лишний PR показывает, что есть следующая страница.
+	rowLimit := -1
+	if limit > 0 {
+		rowLimit = limit + 1
+	}
+	q.args = append(q.args, rowLimit)
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(
+		`SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, COALESCE(pr.team_name, ''), pr.status,
+		        pr.changed_files, pr.required_reviewers, pr.created_at, pr.merged_at, pr.closed_at, pr.force_merged,
+		        pr.version,
+		        (SELECT json_group_array(json_array(reviewer_id, review_state))
+		         FROM (SELECT reviewer_id, review_state FROM pr_reviewers
+		               WHERE pr_id = pr.pull_request_id ORDER BY reviewer_id))
+		 FROM pull_requests pr
+		 WHERE %s
+		 ORDER BY julianday(pr.created_at) %s, pr.pull_request_id %s
+		 LIMIT ?`, where, order, order),
+		q.args...)
+	if err != nil {
+		return domain.PRPage{}, err
+	}
+	defer rows.Close()
+
+	var prs []*domain.PullRequest
+	for rows.Next() {
+		pr := &domain.PullRequest{}
+		var changedFiles, reviewers string
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.TeamName, &pr.Status, &changedFiles,
+			&pr.RequiredReviewers, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt, &pr.ForceMerged, &pr.Version,
+			&reviewers); err != nil {
+			return domain.PRPage{}, err
+		}
+		if err := json.Unmarshal([]byte(changedFiles), &pr.ChangedFiles); err != nil {
+			return domain.PRPage{}, err
+		}
+		var pairs [][2]string
+		if err := json.Unmarshal([]byte(reviewers), &pairs); err != nil {
+			return domain.PRPage{}, err
+		}
+		pr.AssignedReviewers = make([]string, 0, len(pairs))
+		pr.ReviewStates = make(map[string]domain.ReviewState, len(pairs))
+		for _, pair := range pairs {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, pair[0])
+			pr.ReviewStates[pair[0]] = domain.ReviewState(pair[1])
+		}
+		prs = append(prs, pr)
+	}
+	if rows.Err() != nil {
+		return domain.PRPage{}, rows.Err()
+	}
+	return domain.NewPRPage(prs, limit), nil
+}
+
+func (r *SQLiteRepository) GetPullRequestEvents(ctx context.Context, prID string) ([]domain.PREvent, error) {
+	// Читаем журнал в порядке записи.
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT event_id, pr_id, event_type, COALESCE(actor_id, ''), COALESCE(reviewer_id, ''),
+		        COALESCE(old_reviewer_id, ''), COALESCE(details, ''), created_at
+		 FROM pr_events WHERE pr_id = ? ORDER BY event_id`,
+		prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.PREvent{}
+	for rows.Next() {
+		var e domain.PREvent
+		if err := rows.Scan(&e.ID, &e.PullRequestID, &e.Type, &e.ActorID, &e.ReviewerID,
+			&e.OldReviewerID, &e.Details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return events, nil
+}
+
+func (r *SQLiteRepository) ReviewerStats(ctx context.Context, filter domain.StatsFilter) ([]domain.ReviewerStats, error) {
+	// В SQLite нет медианы, поэтому текущие назначения читаются по одному и сводятся здесь.
+	// Они дают открытые ревью и время до слияния; число назначений считается ниже по событиям.
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT r.reviewer_id, pr.status = 'OPEN', (julianday(pr.merged_at) - julianday(r.assigned_at)) * 86400
+		 FROM pr_reviewers r
+		 JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		 WHERE (?1 = '' OR pr.team_name = ?1)
+		   AND (?2 IS NULL OR julianday(r.assigned_at) >= julianday(?2))
+		   AND (?3 IS NULL OR julianday(r.assigned_at) < julianday(?3))`,
+		filter.TeamName, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byUser := make(map[string]*domain.ReviewerStats)
+	userStats := func(userID string) *domain.ReviewerStats {
+		if byUser[userID] == nil {
+			byUser[userID] = &domain.ReviewerStats{UserID: userID}
+		}
+		return byUser[userID]
+	}
+	toMerge := make(map[string][]time.Duration)
+	for rows.Next() {
+		var reviewerID string
+		var open bool
+		var seconds *float64
+		if err := rows.Scan(&reviewerID, &open, &seconds); err != nil {
+			return nil, err
+		}
+		st := userStats(reviewerID)
+		if open {
+			st.OpenReviews++
+		}
+		if d := secondsDuration(seconds); d != nil {
+			toMerge[reviewerID] = append(toMerge[reviewerID], *d)
+		}
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	// Назначения и замены считаются по событиям за период, включая назначения, с которых ревьюера потом сняли.
+	rows, err = r.db.QueryContext(ctx,
+		`SELECT e.event_type, COALESCE(e.reviewer_id, ''), COALESCE(e.old_reviewer_id, '')
+		 FROM pr_events e
+		 JOIN pull_requests pr ON pr.pull_request_id = e.pr_id
+		 WHERE e.event_type IN ('reviewer_assigned', 'reviewer_replaced')
+		   AND (?1 = '' OR pr.team_name = ?1)
+		   AND (?2 IS NULL OR julianday(e.created_at) >= julianday(?2))
+		   AND (?3 IS NULL OR julianday(e.created_at) < julianday(?3))`,
+		filter.TeamName, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventType domain.PREventType
+		var reviewerID, oldReviewerID string
+		if err := rows.Scan(&eventType, &reviewerID, &oldReviewerID); err != nil {
+			return nil, err
+		}
+		if reviewerID != "" {
+			userStats(reviewerID).Assignments++
+		}
+		if eventType == domain.EventReviewerReplaced && oldReviewerID != "" {
+			userStats(oldReviewerID).ReassignedAway++
+		}
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	stats := make([]domain.ReviewerStats, 0, len(byUser))
+	for userID, st := range byUser {
+		st.MedianTimeToMerge = domain.MedianDuration(toMerge[userID])
+		stats = append(stats, *st)
+	}
+	slices.SortFunc(stats, func(a, b domain.ReviewerStats) int { return strings.Compare(a.UserID, b.UserID) })
+	return stats, nil
+}
+
+func (r *SQLiteRepository) TeamStats(ctx context.Context, filter domain.StatsFilter) ([]domain.TeamStats, error) {
+	// PR отбираются по времени создания; команды без PR за период попадают в выдачу с нулями.
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT t.team_name,
+		        COUNT(pr.pull_request_id) FILTER (WHERE pr.status = 'OPEN'),
+		        COUNT(pr.pull_request_id) FILTER (WHERE pr.status = 'MERGED'),
+		        AVG((julianday(pr.merged_at) - julianday(pr.created_at)) * 86400)
+		 FROM teams t
+		 LEFT JOIN pull_requests pr ON pr.team_name = t.team_name
+		      AND (?2 IS NULL OR julianday(pr.created_at) >= julianday(?2))
+		      AND (?3 IS NULL OR julianday(pr.created_at) < julianday(?3))
+		 WHERE ?1 = '' OR t.team_name = ?1
+		 GROUP BY t.team_name
+		 ORDER BY t.team_name`,
+		filter.TeamName, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []domain.TeamStats{}
+	for rows.Next() {
+		var st domain.TeamStats
+		var avg *float64
+		if err := rows.Scan(&st.TeamName, &st.OpenPRs, &st.MergedPRs, &avg); err != nil {
+			return nil, err
+		}
+		st.AvgTimeToMerge = secondsDuration(avg)
+		stats = append(stats, st)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return stats, nil
+}
+
+// apiTokenColumns - колонки токена в порядке scanAPIToken.
+const apiTokenColumns = `token_id, name, role, COALESCE(user_id, ''), created_at, expires_at, revoked_at`
+
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIToken(row rowScanner) (domain.APIToken, error) {
+	var t domain.APIToken
+	err := row.Scan(&t.ID, &t.Name, &t.Role, &t.UserID, &t.CreatedAt, &t.ExpiresAt, &t.RevokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIToken{}, app.ErrTokenNotFound
+	}
+	return t, err
+}
+
+func (r *SQLiteRepository) CreateAPIToken(ctx context.Context, token domain.APIToken, tokenHash string) (domain.APIToken, error) {
+	// Время храним в UTC, как и в остальных таблицах.
+	token.CreatedAt = time.Now().UTC()
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.UTC()
+		token.ExpiresAt = &expiresAt
+	}
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO api_tokens (name, token_hash, role, user_id, created_at, expires_at)
+		 VALUES (?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		token.Name, tokenHash, token.Role, token.UserID, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return domain.APIToken{}, app.ErrUserNotFound
+		}
+		return domain.APIToken{}, err
+	}
+	if token.ID, err = res.LastInsertId(); err != nil {
+		return domain.APIToken{}, err
+	}
+	return token, nil
+}
+
+func (r *SQLiteRepository) GetAPITokenByHash(ctx context.Context, tokenHash string) (domain.APIToken, error) {
+	return scanAPIToken(r.db.QueryRowContext(ctx,
+		`SELECT `+apiTokenColumns+` FROM api_tokens WHERE token_hash = ?`, tokenHash))
+}
+
+func (r *SQLiteRepository) GetAPIToken(ctx context.Context, tokenID int64) (domain.APIToken, error) {
+	return scanAPIToken(r.db.QueryRowContext(ctx,
+		`SELECT `+apiTokenColumns+` FROM api_tokens WHERE token_id = ?`, tokenID))
+}
+
+func (r *SQLiteRepository) ListAPITokens(ctx context.Context, userID string) ([]domain.APIToken, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+apiTokenColumns+` FROM api_tokens WHERE ?1 = '' OR user_id = ?1 ORDER BY token_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]domain.APIToken, 0)
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *SQLiteRepository) RevokeAPIToken(ctx context.Context, tokenID int64, at time.Time) (domain.APIToken, error) {
+	return scanAPIToken(r.db.QueryRowContext(ctx,
+		`UPDATE api_tokens SET revoked_at = COALESCE(revoked_at, ?) WHERE token_id = ?
+		 RETURNING `+apiTokenColumns, at.UTC(), tokenID))
+}
+
+func (r *SQLiteRepository) ReserveIdempotencyKey(ctx context.Context, key, requestHash string, staleBefore, expiredBefore time.Time) (*domain.IdempotencyRecord, bool, error) {
+	// Пытаемся занять ключ или перехватить устаревший; при конфликте с действующим ключом вставка ничего не изменит.
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (idempotency_key, request_hash, created_at) VALUES (?, ?, ?)
+		 ON CONFLICT (idempotency_key) DO UPDATE
+		 SET request_hash = excluded.request_hash, status_code = NULL, response_body = NULL,
+		     content_type = NULL, etag = NULL, created_at = excluded.created_at
+		 WHERE (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < ?)
+		    OR idempotency_keys.created_at < ?`,
+		key, requestHash, time.Now().UTC(), staleBefore.UTC(), expiredBefore.UTC())
+	if err != nil {
+		return nil, false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+	if affected == 1 {
+		return nil, true, nil
+	}
+
+	// Ключ уже занят - возвращаем сохраненную запись.
+	record := &domain.IdempotencyRecord{Key: key}
+	var statusCode sql.NullInt64
+	var contentType, etag sql.NullString
+	err = r.db.QueryRowContext(ctx,
+		`SELECT request_hash, status_code, content_type, etag, response_body, created_at
+		 FROM idempotency_keys WHERE idempotency_key = ?`,
+		key).Scan(&record.RequestHash, &statusCode, &contentType, &etag, &record.Body, &record.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Ключ успели освободить между запросами - пробуем занять заново.
+			return r.ReserveIdempotencyKey(ctx, key, requestHash, staleBefore, expiredBefore)
+		}
+		return nil, false, err
+	}
+	record.StatusCode = int(statusCode.Int64)
+	record.ContentType = contentType.String
+	record.ETag = etag.String
+	return record, false, nil
+}
+
+func (r *SQLiteRepository) SaveIdempotencyResponse(ctx context.Context, response domain.IdempotencyRecord) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status_code = ?, content_type = NULLIF(?, ''), etag = NULLIF(?, ''), response_body = ?
+		 WHERE idempotency_key = ?`,
+		response.StatusCode, response.ContentType, response.ETag, response.Body, response.Key)
+	return err
+}
+
+func (r *SQLiteRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE idempotency_key = ?`, key)
+	return err
+}
+
+func (r *SQLiteRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// getPullRequest читает PR с ревьюерами через переданное соединение или транзакцию.
+func getPullRequest(ctx context.Context, q querier, prID string) (*domain.PullRequest, error) {
+	pr := &domain.PullRequest{}
+
+	// Получаем основную информацию о PR.
+	var changedFiles string
+	err := q.QueryRowContext(ctx,
+		`SELECT pull_request_id, pull_request_name, author_id, COALESCE(team_name, ''), status, changed_files,
+		        required_reviewers, created_at, merged_at, closed_at, force_merged, version
+		 FROM pull_requests WHERE pull_request_id = ?`,
+		prID).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.TeamName, &pr.Status, &changedFiles, &pr.RequiredReviewers,
+		&pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt, &pr.ForceMerged, &pr.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, app.ErrPRNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(changedFiles), &pr.ChangedFiles); err != nil {
+		return nil, err
+	}
+
+	// Получаем ревьюеров этого PR вместе с состоянием их ревью в том же порядке, что и в списках PR.
+	rows, err := q.QueryContext(ctx, `SELECT reviewer_id, review_state FROM pr_reviewers WHERE pr_id = ? ORDER BY reviewer_id`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pr.AssignedReviewers = []string{}
+	pr.ReviewStates = make(map[string]domain.ReviewState)
+	for rows.Next() {
+		var reviewerID string
+		var state domain.ReviewState
+		if err := rows.Scan(&reviewerID, &state); err != nil {
+			return nil, err
+		}
+		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		pr.ReviewStates[reviewerID] = state
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return pr, nil
+}
+
+// addMember добавляет пользователя в команду внутри транзакции. Атрибуты существующего пользователя
+// (имя, активность, вес) не меняются, а его основная команда назначается, только если её не было.
+func addMember(ctx context.Context, tx *sql.Tx, teamName string, member domain.User) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO users (user_id, username, is_active, team_name, review_weight) VALUES (?, ?, ?, ?, ?)
+                           ON CONFLICT (user_id) DO UPDATE SET team_name = COALESCE(users.team_name, excluded.team_name)`,
+		member.ID, member.Username, member.IsActive, teamName, member.ReviewWeight)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO team_members (team_name, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+		teamName, member.ID)
+	return err
+}
+
+// replaceReviewerPools заменяет пулы ревьюеров команды внутри транзакции.
+// Ссылка на несуществующую команду или пользователя возвращает app.ErrInvalidReviewerPool.
+func replaceReviewerPools(ctx context.Context, tx *sql.Tx, teamName string, pools []domain.ReviewerPool) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reviewer_pools WHERE team_name = ?`, teamName); err != nil {
+		return err
+	}
+	for _, pool := range pools {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO reviewer_pools (team_name, mode, source_team) VALUES (?, ?, NULLIF(?, ''))`,
+			teamName, pool.Mode, pool.TeamName)
+		if err != nil {
+			return reviewerPoolError(err)
+		}
+		poolID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, userID := range pool.UserIDs {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO reviewer_pool_users (pool_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+				poolID, userID)
+			if err != nil {
+				return reviewerPoolError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// reviewerPoolError заменяет нарушение внешнего ключа пула на app.ErrInvalidReviewerPool.
+func reviewerPoolError(err error) error {
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("%w: unknown team or user", app.ErrInvalidReviewerPool)
+	}
+	return err
+}
+
+// replaceCodeOwners заменяет правила владения кодом команды внутри транзакции.
+// Ссылка на несуществующего пользователя возвращает app.ErrInvalidCodeOwner.
+func replaceCodeOwners(ctx context.Context, tx *sql.Tx, teamName string, rules []domain.CodeOwnerRule) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM code_owner_rules WHERE team_name = ?`, teamName); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		res, err := tx.ExecContext(ctx, `INSERT INTO code_owner_rules (team_name, pattern) VALUES (?, ?)`,
+			teamName, rule.Pattern)
+		if err != nil {
+			return err
+		}
+		ruleID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, userID := range rule.Owners {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO code_owner_rule_users (rule_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+				ruleID, userID)
+			if err != nil {
+				if isForeignKeyViolation(err) {
+					return fmt.Errorf("%w: unknown user %q", app.ErrInvalidCodeOwner, userID)
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateReviewers заменяет ревьюеров PR внутри транзакции, если версия PR не изменилась.
+// Оставшиеся ревьюеры сохраняют состояние ревью, новые начинают с PENDING.
+func updateReviewers(ctx context.Context, tx *sql.Tx, prID string, version int, reviewers []string, events []domain.PREvent) error {
+	if err := bumpVersion(ctx, tx, prID, version); err != nil {
+		return err
+	}
+
+	// Удаляем ревьюеров, которых нет в новом списке.
+	query := `DELETE FROM pr_reviewers WHERE pr_id = ?`
+	if len(reviewers) > 0 {
+		query += ` AND reviewer_id NOT IN (` + placeholders(len(reviewers)) + `)`
+	}
+	if _, err := tx.ExecContext(ctx, query, append([]any{prID}, stringArgs(reviewers)...)...); err != nil {
+		return err
+	}
+
+	// Добавляем новых ревьюеров, сохраняя состояние ревью у оставшихся.
+	for _, reviewerID := range reviewers {
+		_, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at) VALUES (?, ?, ?)
+                               ON CONFLICT (pr_id, reviewer_id) DO NOTHING`, prID, reviewerID, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	return insertEvents(ctx, tx, events)
+}
+
+// insertEvents добавляет события в журнал внутри транзакции изменения.
+func insertEvents(ctx context.Context, tx *sql.Tx, events []domain.PREvent) error {
+	for _, e := range events {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO pr_events (pr_id, event_type, actor_id, reviewer_id, old_reviewer_id, details, created_at)
+			 VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)`,
+			e.PullRequestID, e.Type, e.ActorID, e.ReviewerID, e.OldReviewerID, e.Details, e.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bumpVersion увеличивает версию PR внутри транзакции, если она совпадает с ожидаемой.
+func bumpVersion(ctx context.Context, tx *sql.Tx, prID string, version int) error {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE pull_requests SET version = version + 1 WHERE pull_request_id = ? AND version = ?`,
+		prID, version)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, tx, res, prID)
+}
+
+// checkAffected объясняет, почему условное обновление PR не затронуло ни одной строки.
+func checkAffected(ctx context.Context, tx *sql.Tx, res sql.Result, prID string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = ?)`, prID).
+		Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return app.ErrPRNotFound
+	}
+	slog.DebugContext(ctx, "pull request version conflict", "pull_request_id", prID)
+	return app.ErrVersionConflict
+}
+
+// isUniqueViolation сообщает, что вставка нарушила первичный ключ или уникальный индекс.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+// isForeignKeyViolation сообщает, что запись ссылается на несуществующую строку.
+func isForeignKeyViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
+
+// placeholders возвращает список из n параметров для IN (...).
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// secondsDuration переводит число секунд из SQL в длительность; NULL остается nil.
+func secondsDuration(seconds *float64) *time.Duration {
+	if seconds == nil {
+		return nil
+	}
+	d := time.Duration(*seconds * float64(time.Second))
+	return &d
+}
+
+// listQuery накапливает условия WHERE списка PR и их параметры.
+type listQuery struct {
+	conds []string
+	args  []any
+}
+
+// add добавляет условие вместе с параметрами его "?".
+func (q *listQuery) add(cond string, args ...any) {
+	q.conds = append(q.conds, cond)
+	q.args = append(q.args, args...)
+}
+
+func statusArgs(statuses []domain.PRStatus) []any {
+	args := make([]any, len(statuses))
+	for i, status := range statuses {
+		args[i] = string(status)
+	}
+	return args
+}
+
+func stringArgs(values []string) []any {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
@@ -0,0 +1,39 @@
+package sqlite_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"github.com/wsppppp/manage-pull-request/internal/repository"
+	"github.com/wsppppp/manage-pull-request/internal/repository/repotest"
+	"github.com/wsppppp/manage-pull-request/internal/repository/sqlite"
+	"github.com/wsppppp/manage-pull-request/pkg/database"
+)
+
+// TestRepository запускает каждую проверку на новой базе во временном каталоге подтеста.
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		path := filepath.Join(t.TempDir(), "reviewer.db")
+
+		m, err := migrate.New("file://../../../migrations/sqlite", "sqlite3://"+path+"?_foreign_keys=on&x-migrations-table="+database.MigrationsTable)
+		if err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			t.Fatalf("migrate up: %v", err)
+		}
+		m.Close()
+
+		db, err := database.NewSQLiteClient(t.Context(), path)
+		if err != nil {
+			t.Fatalf("open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return sqlite.New(db)
+	})
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/logging"
+)
+
+// authenticate пропускает только запросы с действующим токеном из заголовка Authorization: Bearer <token>.
+// Токен сохраняется в контексте для проверки прав, а его пользователь становится автором изменений в журнале событий.
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, secret, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		secret = strings.TrimSpace(secret)
+		if !strings.EqualFold(scheme, "Bearer") || secret == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, r, "UNAUTHORIZED", "bearer token is required", http.StatusUnauthorized, nil)
+			return
+		}
+
+		token, err := h.service.Authenticate(r.Context(), secret)
+		if err != nil {
+			if errors.Is(err, app.ErrUnauthenticated) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, r, "UNAUTHORIZED", err.Error(), http.StatusUnauthorized, err)
+				return
+			}
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx := app.WithPrincipal(r.Context(), token)
+		ctx = app.WithActor(ctx, token.Actor())
+		logging.AddAttrs(ctx, "actor_id", token.Actor(), "token_id", token.ID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *Handler) createAPIToken(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+
+	token, secret, err := h.service.CreateAPIToken(r.Context(), domain.APIToken{
+		Name:      req.Name,
+		Role:      domain.Role(req.Role),
+		UserID:    req.UserID,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrInvalidAPIToken):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, app.ErrUserNotFound):
+			writeError(w, r, "NOT_FOUND", "user not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{"token": fromDomainAPIToken(token), "secret": secret})
+}
+
+func (h *Handler) listAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.service.ListAPITokens(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+			return
+		}
+		writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		return
+	}
+
+	dtos := make([]APITokenDTO, len(tokens))
+	for i, token := range tokens {
+		dtos[i] = fromDomainAPIToken(token)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"tokens": dtos})
+}
+
+func (h *Handler) revokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	var req RevokeAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+	if req.TokenID == 0 {
+		writeError(w, r, "INVALID_REQUEST", "token_id is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	token, err := h.service.RevokeAPIToken(r.Context(), req.TokenID)
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrTokenNotFound):
+			writeError(w, r, "NOT_FOUND", "api token not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"token": fromDomainAPIToken(token)})
+}
+
+// getCurrentAPIToken возвращает токен, которым выполнен запрос.
+func (h *Handler) getCurrentAPIToken(w http.ResponseWriter, r *http.Request) {
+	token, _ := app.PrincipalFromContext(r.Context())
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"token": fromDomainAPIToken(token)})
+}
+
+// idempotencyScope отделяет ключи идемпотентности разных токенов, чтобы один клиент
+// не получил сохраненный ответ другого.
+func idempotencyScope(r *http.Request) string {
+	token, ok := app.PrincipalFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	return strconv.FormatInt(token.ID, 10) + ":"
+}
@@ -1,37 +1,133 @@
 package http
 
 import (
-	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"slices"
 	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
 )
 
+// defaultReviewWeight - вес участника, если он не указан в запросе.
+const defaultReviewWeight = 1
+
 // TeamMemberDTO - модель участника команды для API.
 type TeamMemberDTO struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	IsActive     bool   `json:"is_active"`
+	ReviewWeight *int   `json:"review_weight,omitempty"`
 }
 
-// TeamDTO - модель команды для API.
+// TeamDTO - модель команды для API. Незаданные настройки заполняются значениями по умолчанию.
 type TeamDTO struct {
-	Name    string          `json:"team_name"`
-	Members []TeamMemberDTO `json:"members"`
+	Name              string             `json:"team_name"`
+	ReviewerStrategy  string             `json:"reviewer_strategy,omitempty"`
+	MinReviewers      *int               `json:"min_reviewers,omitempty"`
+	MaxReviewers      *int               `json:"max_reviewers,omitempty"`
+	MergePolicy       string             `json:"merge_policy,omitempty"`
+	RequiredApprovals *int               `json:"required_approvals,omitempty"`
+	Members           []TeamMemberDTO    `json:"members"`
+	ReviewerPools     []ReviewerPoolDTO  `json:"reviewer_pools"`
+	CodeOwners        []CodeOwnerRuleDTO `json:"code_owners"`
+}
+
+// ReviewerPoolDTO - модель пула ревьюеров команды для API.
+type ReviewerPoolDTO struct {
+	Mode     string   `json:"mode"`
+	TeamName string   `json:"team_name,omitempty"`
+	UserIDs  []string `json:"user_ids,omitempty"`
+}
+
+// toDomainReviewerPools конвертирует пулы ревьюеров из DTO в доменную модель.
+func toDomainReviewerPools(dtos []ReviewerPoolDTO) []domain.ReviewerPool {
+	pools := make([]domain.ReviewerPool, len(dtos))
+	for i, p := range dtos {
+		pools[i] = domain.ReviewerPool{Mode: domain.ReviewerPoolMode(p.Mode), TeamName: p.TeamName, UserIDs: p.UserIDs}
+	}
+	return pools
+}
+
+// fromDomainReviewerPools конвертирует пулы ревьюеров в DTO.
+func fromDomainReviewerPools(pools []domain.ReviewerPool) []ReviewerPoolDTO {
+	dtos := make([]ReviewerPoolDTO, len(pools))
+	for i, p := range pools {
+		dtos[i] = ReviewerPoolDTO{Mode: string(p.Mode), TeamName: p.TeamName, UserIDs: p.UserIDs}
+	}
+	return dtos
+}
+
+// CodeOwnerRuleDTO - модель правила владения кодом для API.
+type CodeOwnerRuleDTO struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+// toDomainCodeOwners конвертирует правила владения кодом из DTO в доменную модель.
+func toDomainCodeOwners(dtos []CodeOwnerRuleDTO) []domain.CodeOwnerRule {
+	rules := make([]domain.CodeOwnerRule, len(dtos))
+	for i, r := range dtos {
+		rules[i] = domain.CodeOwnerRule{Pattern: r.Pattern, Owners: r.Owners}
+	}
+	return rules
+}
+
+// fromDomainCodeOwners конвертирует правила владения кодом в DTO.
+func fromDomainCodeOwners(rules []domain.CodeOwnerRule) []CodeOwnerRuleDTO {
+	dtos := make([]CodeOwnerRuleDTO, len(rules))
+	for i, r := range rules {
+		owners := r.Owners
+		if owners == nil {
+			owners = []string{}
+		}
+		dtos[i] = CodeOwnerRuleDTO{Pattern: r.Pattern, Owners: owners}
+	}
+	return dtos
 }
 
 // toDomainTeam конвертирует DTO в доменную модель Team.
 func toDomainTeam(dto TeamDTO) domain.Team {
 	members := make([]domain.User, len(dto.Members))
 	for i, m := range dto.Members {
-		members[i] = domain.User{
-			ID:       m.UserID,
-			Username: m.Username,
-			IsActive: m.IsActive,
-			TeamName: dto.Name,
-		}
+		members[i] = toDomainMember(dto.Name, m)
+	}
+	settings := domain.DefaultTeamSettings()
+	if dto.ReviewerStrategy != "" {
+		settings.ReviewerStrategy = domain.ReviewerStrategy(dto.ReviewerStrategy)
+	}
+	if dto.MinReviewers != nil {
+		settings.MinReviewers = *dto.MinReviewers
+	}
+	if dto.MaxReviewers != nil {
+		settings.MaxReviewers = *dto.MaxReviewers
+	}
+	if dto.MergePolicy != "" {
+		settings.MergePolicy = domain.MergePolicy(dto.MergePolicy)
+	}
+	if dto.RequiredApprovals != nil {
+		settings.RequiredApprovals = *dto.RequiredApprovals
 	}
 	return domain.Team{
-		Name:    dto.Name,
-		Members: members,
+		Name:          dto.Name,
+		TeamSettings:  settings,
+		Members:       members,
+		ReviewerPools: toDomainReviewerPools(dto.ReviewerPools),
+		CodeOwners:    toDomainCodeOwners(dto.CodeOwners),
+	}
+}
+
+// toDomainMember конвертирует участника команды в доменную модель User.
+func toDomainMember(teamName string, m TeamMemberDTO) domain.User {
+	weight := defaultReviewWeight
+	if m.ReviewWeight != nil {
+		weight = *m.ReviewWeight
+	}
+	return domain.User{
+		ID:           m.UserID,
+		Username:     m.Username,
+		IsActive:     m.IsActive,
+		TeamName:     teamName,
+		ReviewWeight: weight,
 	}
 }
 
@@ -39,15 +135,176 @@ func toDomainTeam(dto TeamDTO) domain.Team {
 func fromDomainTeam(team domain.Team) TeamDTO {
 	members := make([]TeamMemberDTO, len(team.Members))
 	for i, m := range team.Members {
+		weight := m.ReviewWeight
 		members[i] = TeamMemberDTO{
-			UserID:   m.ID,
-			Username: m.Username,
-			IsActive: m.IsActive,
+			UserID:       m.ID,
+			Username:     m.Username,
+			IsActive:     m.IsActive,
+			ReviewWeight: &weight,
 		}
 	}
 	return TeamDTO{
-		Name:    team.Name,
-		Members: members,
+		Name:              team.Name,
+		ReviewerStrategy:  string(team.ReviewerStrategy),
+		MinReviewers:      &team.MinReviewers,
+		MaxReviewers:      &team.MaxReviewers,
+		MergePolicy:       string(team.MergePolicy),
+		RequiredApprovals: &team.RequiredApprovals,
+		Members:           members,
+		ReviewerPools:     fromDomainReviewerPools(team.ReviewerPools),
+		CodeOwners:        fromDomainCodeOwners(team.CodeOwners),
+	}
+}
+
+// AddTeamMemberRequest - модель запроса для добавления участника в команду.
+type AddTeamMemberRequest struct {
+	TeamName string `json:"team_name"`
+	TeamMemberDTO
+}
+
+// TeamMemberRequest - модель запроса для исключения участника из команды.
+type TeamMemberRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+}
+
+// RenameTeamRequest - модель запроса для переименования команды.
+type RenameTeamRequest struct {
+	TeamName    string `json:"team_name"`
+	NewTeamName string `json:"new_team_name"`
+}
+
+// SetReviewerPoolsRequest - модель запроса для замены пулов ревьюеров команды.
+type SetReviewerPoolsRequest struct {
+	TeamName      string            `json:"team_name"`
+	ReviewerPools []ReviewerPoolDTO `json:"reviewer_pools"`
+}
+
+// SetCodeOwnersRequest - модель запроса для замены правил владения кодом команды.
+type SetCodeOwnersRequest struct {
+	TeamName   string             `json:"team_name"`
+	CodeOwners []CodeOwnerRuleDTO `json:"code_owners"`
+}
+
+// TeamNameRequest - модель запроса с одним именем команды.
+type TeamNameRequest struct {
+	TeamName string `json:"team_name"`
+}
+
+// ReviewerReassignmentDTO - замена ревьюера на PR.
+type ReviewerReassignmentDTO struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+// MemberRemovalDTO - результат исключения участника из команды.
+type MemberRemovalDTO struct {
+	TeamName      string                    `json:"team_name"`
+	UserID        string                    `json:"user_id"`
+	Reassigned    []ReviewerReassignmentDTO `json:"reassigned"`
+	UnderReviewed []string                  `json:"under_reviewed"`
+}
+
+func fromMemberRemoval(removal *app.MemberRemoval) MemberRemovalDTO {
+	return MemberRemovalDTO{
+		TeamName:      removal.TeamName,
+		UserID:        removal.UserID,
+		Reassigned:    fromReviewerReassignments(removal.Reassigned),
+		UnderReviewed: removal.UnderReviewed,
+	}
+}
+
+func fromReviewerReassignments(reassignments []app.ReviewerReassignment) []ReviewerReassignmentDTO {
+	dtos := make([]ReviewerReassignmentDTO, len(reassignments))
+	for i, r := range reassignments {
+		dtos[i] = ReviewerReassignmentDTO{
+			PullRequestID: r.PullRequestID,
+			OldReviewerID: r.OldReviewerID,
+			NewReviewerID: r.NewReviewerID,
+		}
+	}
+	return dtos
+}
+
+// DeactivateRequest - модель запроса для деактивации одного пользователя (user_id) или всей команды (team_name).
+type DeactivateRequest struct {
+	UserID   string `json:"user_id,omitempty"`
+	TeamName string `json:"team_name,omitempty"`
+}
+
+// UnreplacedReviewerDTO - ревьюер, для которого не нашлось замены.
+type UnreplacedReviewerDTO struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+}
+
+// DeactivationDTO - отчет о деактивации пользователей.
+type DeactivationDTO struct {
+	UserIDs     []string                  `json:"user_ids"`
+	Reassigned  []ReviewerReassignmentDTO `json:"reassigned"`
+	NoCandidate []UnreplacedReviewerDTO   `json:"no_candidate"`
+}
+
+func fromDeactivation(report *app.Deactivation) DeactivationDTO {
+	noCandidate := make([]UnreplacedReviewerDTO, len(report.NoCandidate))
+	for i, r := range report.NoCandidate {
+		noCandidate[i] = UnreplacedReviewerDTO{PullRequestID: r.PullRequestID, ReviewerID: r.ReviewerID}
+	}
+	return DeactivationDTO{
+		UserIDs:     report.UserIDs,
+		Reassigned:  fromReviewerReassignments(report.Reassigned),
+		NoCandidate: noCandidate,
+	}
+}
+
+// UpdateTeamSettingsRequest - модель запроса для изменения настроек команды.
+type UpdateTeamSettingsRequest struct {
+	TeamName          string  `json:"team_name"`
+	ReviewerStrategy  *string `json:"reviewer_strategy,omitempty"`
+	MinReviewers      *int    `json:"min_reviewers,omitempty"`
+	MaxReviewers      *int    `json:"max_reviewers,omitempty"`
+	MergePolicy       *string `json:"merge_policy,omitempty"`
+	RequiredApprovals *int    `json:"required_approvals,omitempty"`
+}
+
+// toSettingsUpdate конвертирует запрос в частичное обновление настроек.
+func (req UpdateTeamSettingsRequest) toSettingsUpdate() app.TeamSettingsUpdate {
+	update := app.TeamSettingsUpdate{
+		MinReviewers:      req.MinReviewers,
+		MaxReviewers:      req.MaxReviewers,
+		RequiredApprovals: req.RequiredApprovals,
+	}
+	if req.ReviewerStrategy != nil {
+		strategy := domain.ReviewerStrategy(*req.ReviewerStrategy)
+		update.ReviewerStrategy = &strategy
+	}
+	if req.MergePolicy != nil {
+		policy := domain.MergePolicy(*req.MergePolicy)
+		update.MergePolicy = &policy
+	}
+	return update
+}
+
+// TeamSettingsDTO - модель настроек команды для API ответа.
+type TeamSettingsDTO struct {
+	TeamName          string `json:"team_name"`
+	ReviewerStrategy  string `json:"reviewer_strategy"`
+	MinReviewers      int    `json:"min_reviewers"`
+	MaxReviewers      int    `json:"max_reviewers"`
+	MergePolicy       string `json:"merge_policy"`
+	RequiredApprovals int    `json:"required_approvals"`
+}
+
+// fromDomainTeamSettings конвертирует настройки команды в DTO.
+func fromDomainTeamSettings(teamName string, settings domain.TeamSettings) TeamSettingsDTO {
+	return TeamSettingsDTO{
+		TeamName:          teamName,
+		ReviewerStrategy:  string(settings.ReviewerStrategy),
+		MinReviewers:      settings.MinReviewers,
+		MaxReviewers:      settings.MaxReviewers,
+		MergePolicy:       string(settings.MergePolicy),
+		RequiredApprovals: settings.RequiredApprovals,
 	}
 }
 
@@ -59,10 +316,11 @@ type SetUserActivityRequest struct {
 
 // UserDTO - модель пользователя для API ответа.
 type UserDTO struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username"`
+	TeamName string   `json:"team_name"`
+	Teams    []string `json:"teams"`
+	IsActive bool     `json:"is_active"`
 }
 
 // fromDomainUser конвертирует доменную модель User (указатель) в DTO.
@@ -74,31 +332,121 @@ func fromDomainUser(user *domain.User) UserDTO { // Принимает *domain.U
 		UserID:   user.ID,
 		Username: user.Username,
 		TeamName: user.TeamName,
+		Teams:    user.Teams,
 		IsActive: user.IsActive,
 	}
 }
 
+// AddAbsenceRequest - модель запроса для планирования отсутствия. Без starts_at отсутствие начинается сразу.
+type AddAbsenceRequest struct {
+	UserID   string     `json:"user_id"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   time.Time  `json:"ends_at"`
+	Reason   string     `json:"reason"`
+}
+
+// CancelAbsenceRequest - модель запроса для отмены отсутствия.
+type CancelAbsenceRequest struct {
+	AbsenceID int64 `json:"absence_id"`
+}
+
+// AbsenceDTO - модель отсутствия пользователя для API ответа.
+type AbsenceDTO struct {
+	AbsenceID int64     `json:"absence_id"`
+	UserID    string    `json:"user_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func fromDomainAbsence(a domain.Absence) AbsenceDTO {
+	return AbsenceDTO{
+		AbsenceID: a.ID,
+		UserID:    a.UserID,
+		StartsAt:  a.StartsAt,
+		EndsAt:    a.EndsAt,
+		Reason:    a.Reason,
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+// CreateAPITokenRequest - модель запроса для выпуска токена API. Без expires_at токен бессрочный.
+type CreateAPITokenRequest struct {
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	UserID    string     `json:"user_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// RevokeAPITokenRequest - модель запроса для отзыва токена API.
+type RevokeAPITokenRequest struct {
+	TokenID int64 `json:"token_id"`
+}
+
+// APITokenDTO - модель токена API для API ответа. Секрет в нее не входит.
+type APITokenDTO struct {
+	TokenID   int64      `json:"token_id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	UserID    string     `json:"user_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func fromDomainAPIToken(t domain.APIToken) APITokenDTO {
+	return APITokenDTO{
+		TokenID:   t.ID,
+		Name:      t.Name,
+		Role:      string(t.Role),
+		UserID:    t.UserID,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+	}
+}
+
 // CreatePullRequestRequest - модель запроса для создания PR.
+// TeamName выбирает, из какой команды автора назначать ревьюеров; по умолчанию - основная команда.
+// По ChangedFiles первыми назначаются владельцы измененных файлов.
 type CreatePullRequestRequest struct {
-	ID       string `json:"pull_request_id"`
-	Name     string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
+	ID           string   `json:"pull_request_id"`
+	Name         string   `json:"pull_request_name"`
+	AuthorID     string   `json:"author_id"`
+	TeamName     string   `json:"team_name,omitempty"`
+	ChangedFiles []string `json:"changed_files,omitempty"`
+	Draft        bool     `json:"draft"`
+}
+
+// PullRequestActionRequest - модель запроса для смены статуса PR (ready, close, reopen).
+type PullRequestActionRequest struct {
+	PullRequestID string `json:"pull_request_id"`
 }
 
 // MergePullRequestRequest - модель запроса для слияния PR.
+// Force обходит политику слияния и доступен только администратору.
 type MergePullRequestRequest struct {
 	PullRequestID string `json:"pull_request_id"`
+	Force         bool   `json:"force"`
 }
 
 // PullRequestDTO - модель PR для API ответа.
 type PullRequestDTO struct {
-	ID                string     `json:"pull_request_id"`
-	Name              string     `json:"pull_request_name"`
-	AuthorID          string     `json:"author_id"`
-	Status            string     `json:"status"`
-	AssignedReviewers []string   `json:"assigned_reviewers"`
-	CreatedAt         time.Time  `json:"createdAt"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	ID                string            `json:"pull_request_id"`
+	Name              string            `json:"pull_request_name"`
+	AuthorID          string            `json:"author_id"`
+	TeamName          string            `json:"team_name"`
+	Status            string            `json:"status"`
+	ChangedFiles      []string          `json:"changed_files"`
+	AssignedReviewers []string          `json:"assigned_reviewers"`
+	ReviewStates      map[string]string `json:"review_states"`
+	UnderReviewed     bool              `json:"under_reviewed"`
+	CreatedAt         time.Time         `json:"createdAt"`
+	MergedAt          *time.Time        `json:"mergedAt,omitempty"`
+	ClosedAt          *time.Time        `json:"closedAt,omitempty"`
+	ForceMerged       bool              `json:"force_merged"`
+	Version           int               `json:"version"`
 }
 
 // fromDomainPR конвертирует доменную модель PullRequest (указатель) в DTO.
@@ -106,14 +454,28 @@ func fromDomainPR(pr *domain.PullRequest) PullRequestDTO {
 	if pr == nil {
 		return PullRequestDTO{}
 	}
+	reviewStates := make(map[string]string, len(pr.ReviewStates))
+	for reviewerID, state := range pr.ReviewStates {
+		reviewStates[reviewerID] = string(state)
+	}
+	// Ответы на создание и переназначение возвращают ревьюеров в том же порядке, что и чтение PR.
+	reviewers := append([]string{}, pr.AssignedReviewers...)
+	slices.Sort(reviewers)
 	return PullRequestDTO{
 		ID:                pr.ID,
 		Name:              pr.Name,
 		AuthorID:          pr.AuthorID,
+		TeamName:          pr.TeamName,
 		Status:            string(pr.Status),
-		AssignedReviewers: pr.AssignedReviewers,
+		ChangedFiles:      pr.ChangedFiles,
+		AssignedReviewers: reviewers,
+		ReviewStates:      reviewStates,
+		UnderReviewed:     pr.UnderReviewed(),
 		CreatedAt:         pr.CreatedAt,
 		MergedAt:          pr.MergedAt,
+		ClosedAt:          pr.ClosedAt,
+		ForceMerged:       pr.ForceMerged,
+		Version:           pr.Version,
 	}
 }
 
@@ -123,19 +485,97 @@ type ReassignReviewerRequest struct {
 	OldReviewerID string `json:"old_reviewer_id"`
 }
 
+// SubmitReviewRequest - модель запроса для решения ревьюера.
+type SubmitReviewRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	State         string `json:"state"`
+}
+
+// PREventDTO - запись журнала PR для /pullRequest/history.
+type PREventDTO struct {
+	EventID       int64     `json:"event_id"`
+	PullRequestID string    `json:"pull_request_id"`
+	EventType     string    `json:"event_type"`
+	ActorID       string    `json:"actor_id,omitempty"`
+	ReviewerID    string    `json:"reviewer_id,omitempty"`
+	OldReviewerID string    `json:"old_reviewer_id,omitempty"`
+	Details       string    `json:"details,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func fromDomainPREvent(e domain.PREvent) PREventDTO {
+	return PREventDTO{
+		EventID:       e.ID,
+		PullRequestID: e.PullRequestID,
+		EventType:     string(e.Type),
+		ActorID:       e.ActorID,
+		ReviewerID:    e.ReviewerID,
+		OldReviewerID: e.OldReviewerID,
+		Details:       e.Details,
+		CreatedAt:     e.CreatedAt,
+	}
+}
+
 // PullRequestShortDTO - укороченная версия для /users/getReview.
 type PullRequestShortDTO struct {
-	ID       string `json:"pull_request_id"`
-	Name     string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
-	Status   string `json:"status"`
+	ID        string    `json:"pull_request_id"`
+	Name      string    `json:"pull_request_name"`
+	AuthorID  string    `json:"author_id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 func fromDomainPRtoShort(pr *domain.PullRequest) PullRequestShortDTO {
 	return PullRequestShortDTO{
-		ID:       pr.ID,
-		Name:     pr.Name,
-		AuthorID: pr.AuthorID,
-		Status:   string(pr.Status),
+		ID:        pr.ID,
+		Name:      pr.Name,
+		AuthorID:  pr.AuthorID,
+		Status:    string(pr.Status),
+		CreatedAt: pr.CreatedAt,
+	}
+}
+
+// ReviewerStatsDTO - нагрузка ревьюера для /stats/reviewers. Время до слияния в секундах, null - слитых PR нет.
+type ReviewerStatsDTO struct {
+	UserID                   string   `json:"user_id"`
+	Assignments              int      `json:"assignments"`
+	OpenReviews              int      `json:"open_reviews"`
+	ReassignedAway           int      `json:"reassigned_away"`
+	MedianTimeToMergeSeconds *float64 `json:"median_time_to_merge_seconds"`
+}
+
+func fromDomainReviewerStats(st domain.ReviewerStats) ReviewerStatsDTO {
+	return ReviewerStatsDTO{
+		UserID:                   st.UserID,
+		Assignments:              st.Assignments,
+		OpenReviews:              st.OpenReviews,
+		ReassignedAway:           st.ReassignedAway,
+		MedianTimeToMergeSeconds: durationSeconds(st.MedianTimeToMerge),
+	}
+}
+
+// TeamStatsDTO - статистика PR команды для /stats/teams. Время до слияния в секундах, null - слитых PR нет.
+type TeamStatsDTO struct {
+	TeamName              string   `json:"team_name"`
+	OpenPRs               int      `json:"open_prs"`
+	MergedPRs             int      `json:"merged_prs"`
+	AvgTimeToMergeSeconds *float64 `json:"avg_time_to_merge_seconds"`
+}
+
+func fromDomainTeamStats(st domain.TeamStats) TeamStatsDTO {
+	return TeamStatsDTO{
+		TeamName:              st.TeamName,
+		OpenPRs:               st.OpenPRs,
+		MergedPRs:             st.MergedPRs,
+		AvgTimeToMergeSeconds: durationSeconds(st.AvgTimeToMerge),
+	}
+}
+
+func durationSeconds(d *time.Duration) *float64 {
+	if d == nil {
+		return nil
 	}
+	seconds := d.Seconds()
+	return &seconds
 }
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+var errInvalidIfMatch = errors.New("If-Match must be a quoted pull request version")
+
+// etag возвращает ETag для версии PR.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setETag выставляет ETag с версией PR. Вызывается до WriteHeader.
+func setETag(w http.ResponseWriter, pr *domain.PullRequest) {
+	if pr != nil {
+		w.Header().Set("ETag", etag(pr.Version))
+	}
+}
+
+// ifMatchVersion разбирает заголовок If-Match. Возвращает 0, если заголовка нет или он равен "*".
+func ifMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// notModified сообщает, что версия PR совпадает с одним из тегов If-None-Match.
+func notModified(r *http.Request, pr *domain.PullRequest) bool {
+	current := etag(pr.Version)
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
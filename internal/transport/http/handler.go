@@ -1,37 +1,55 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/wsppppp/manage-pull-request/internal/app"
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+	"github.com/wsppppp/manage-pull-request/internal/health"
 )
 
 type Handler struct {
-	service *app.Service
+	service          *app.Service
+	idempotencyStore IdempotencyStore
+	health           *health.Checker
 }
 
-func NewHandler(service *app.Service) *Handler {
-	return &Handler{service: service}
+// NewHandler создает обработчик. checker отвечает на проверку готовности /readyz.
+func NewHandler(service *app.Service, idempotencyStore IdempotencyStore, checker *health.Checker) *Handler {
+	return &Handler{service: service, idempotencyStore: idempotencyStore, health: checker}
 }
 
 func (h *Handler) createTeam(w http.ResponseWriter, r *http.Request) {
 	var teamDTO TeamDTO
 	if err := json.NewDecoder(r.Body).Decode(&teamDTO); err != nil {
-		writeError(w, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
 		return
 	}
 
 	team, err := h.service.CreateTeam(r.Context(), toDomainTeam(teamDTO))
 	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+			return
+		}
 		var teamExistsErr *app.ErrTeamExists
 		if errors.As(err, &teamExistsErr) {
-			writeError(w, "TEAM_EXISTS", teamExistsErr.Error(), http.StatusBadRequest, teamExistsErr)
+			writeError(w, r, "TEAM_EXISTS", teamExistsErr.Error(), http.StatusBadRequest, teamExistsErr)
+			return
+		}
+		if errors.Is(err, app.ErrUnknownStrategy) || errors.Is(err, app.ErrInvalidTeamSettings) ||
+			errors.Is(err, app.ErrInvalidReviewWeight) || errors.Is(err, app.ErrInvalidReviewerPool) ||
+			errors.Is(err, app.ErrInvalidCodeOwner) {
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
 			return
 		}
-		writeError(w, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -42,88 +60,611 @@ func (h *Handler) createTeam(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) getTeam(w http.ResponseWriter, r *http.Request) {
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		writeError(w, "INVALID_REQUEST", "team_name is required", http.StatusBadRequest, nil)
+		writeError(w, r, "INVALID_REQUEST", "team_name is required", http.StatusBadRequest, nil)
 		return
 	}
 
 	team, err := h.service.GetTeam(r.Context(), teamName)
 	if err != nil {
 		if errors.Is(err, app.ErrNotFound) {
-			writeError(w, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
 			return
 		}
-		writeError(w, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(fromDomainTeam(team))
 }
 
+func (h *Handler) updateTeamSettings(w http.ResponseWriter, r *http.Request) {
+	var req UpdateTeamSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+	if req.TeamName == "" {
+		writeError(w, r, "INVALID_REQUEST", "team_name is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	settings, err := h.service.UpdateTeamSettings(r.Context(), req.TeamName, req.toSettingsUpdate())
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrUnknownStrategy), errors.Is(err, app.ErrInvalidTeamSettings):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"settings": fromDomainTeamSettings(req.TeamName, settings)})
+}
+
+func (h *Handler) setReviewerPools(w http.ResponseWriter, r *http.Request) {
+	var req SetReviewerPoolsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+	if req.TeamName == "" {
+		writeError(w, r, "INVALID_REQUEST", "team_name is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	team, err := h.service.SetReviewerPools(r.Context(), req.TeamName, toDomainReviewerPools(req.ReviewerPools))
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrInvalidReviewerPool):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"team": fromDomainTeam(team)})
+}
+
+func (h *Handler) setCodeOwners(w http.ResponseWriter, r *http.Request) {
+	var req SetCodeOwnersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+	if req.TeamName == "" {
+		writeError(w, r, "INVALID_REQUEST", "team_name is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	team, err := h.service.SetCodeOwners(r.Context(), req.TeamName, toDomainCodeOwners(req.CodeOwners))
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrInvalidCodeOwner):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"team": fromDomainTeam(team)})
+}
+
+func (h *Handler) addTeamMember(w http.ResponseWriter, r *http.Request) {
+	var req AddTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+	if req.TeamName == "" || req.UserID == "" {
+		writeError(w, r, "INVALID_REQUEST", "team_name and user_id are required", http.StatusBadRequest, nil)
+		return
+	}
+
+	team, err := h.service.AddTeamMember(r.Context(), req.TeamName, toDomainMember(req.TeamName, req.TeamMemberDTO))
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrInvalidReviewWeight):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"team": fromDomainTeam(team)})
+}
+
+func (h *Handler) removeTeamMember(w http.ResponseWriter, r *http.Request) {
+	var req TeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+
+	removal, err := h.service.RemoveTeamMember(r.Context(), req.TeamName, req.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrNotTeamMember):
+			writeError(w, r, "NOT_FOUND", err.Error(), http.StatusNotFound, err)
+		case errors.Is(err, app.ErrVersionConflict):
+			writeError(w, r, "VERSION_CONFLICT", "pull requests are being modified concurrently, retry", http.StatusConflict, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fromMemberRemoval(removal))
+}
+
+func (h *Handler) renameTeam(w http.ResponseWriter, r *http.Request) {
+	var req RenameTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+
+	team, err := h.service.RenameTeam(r.Context(), req.TeamName, req.NewTeamName)
+	if err != nil {
+		var teamExistsErr *app.ErrTeamExists
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.As(err, &teamExistsErr):
+			writeError(w, r, "TEAM_EXISTS", teamExistsErr.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, app.ErrInvalidTeamName):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"team": fromDomainTeam(team)})
+}
+
+func (h *Handler) deleteTeam(w http.ResponseWriter, r *http.Request) {
+	var req TeamNameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.DeleteTeam(r.Context(), req.TeamName); err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrTeamHasOpenPRs):
+			writeError(w, r, "TEAM_HAS_OPEN_PRS", err.Error(), http.StatusConflict, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"team_name": req.TeamName})
+}
+
 func (h *Handler) setUserActivity(w http.ResponseWriter, r *http.Request) {
 	var req SetUserActivityRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
 		return
 	}
 
 	user, err := h.service.SetUserActivity(r.Context(), req.UserID, req.IsActive)
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrUserNotFound):
+			writeError(w, r, "NOT_FOUND", "user not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"user": fromDomainUser(user)})
+}
+
+func (h *Handler) deactivateUsers(w http.ResponseWriter, r *http.Request) {
+	var req DeactivateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+	if (req.UserID == "") == (req.TeamName == "") {
+		writeError(w, r, "INVALID_REQUEST", "exactly one of user_id and team_name is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	var (
+		report *app.Deactivation
+		err    error
+	)
+	if req.UserID != "" {
+		report, err = h.service.DeactivateUser(r.Context(), req.UserID)
+	} else {
+		report, err = h.service.DeactivateTeam(r.Context(), req.TeamName)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrUserNotFound):
+			writeError(w, r, "NOT_FOUND", "user not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrVersionConflict):
+			writeError(w, r, "VERSION_CONFLICT", "pull requests are being modified concurrently, retry", http.StatusConflict, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fromDeactivation(report))
+}
+
+func (h *Handler) addAbsence(w http.ResponseWriter, r *http.Request) {
+	var req AddAbsenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+	if req.UserID == "" || req.EndsAt.IsZero() {
+		writeError(w, r, "INVALID_REQUEST", "user_id and ends_at are required", http.StatusBadRequest, nil)
+		return
+	}
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+
+	absence, err := h.service.AddAbsence(r.Context(), req.UserID, startsAt, req.EndsAt, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrInvalidAbsence):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, app.ErrUserNotFound):
+			writeError(w, r, "NOT_FOUND", "user not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{"absence": fromDomainAbsence(absence)})
+}
+
+func (h *Handler) getAbsences(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, r, "INVALID_REQUEST", "user_id is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	absences, err := h.service.GetAbsences(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, app.ErrUserNotFound) {
-			writeError(w, "NOT_FOUND", "user not found", http.StatusNotFound, err)
+			writeError(w, r, "NOT_FOUND", "user not found", http.StatusNotFound, err)
 			return
 		}
-		writeError(w, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
 		return
 	}
 
+	dtos := make([]AbsenceDTO, len(absences))
+	for i, a := range absences {
+		dtos[i] = fromDomainAbsence(a)
+	}
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]any{"user": fromDomainUser(user)})
+	json.NewEncoder(w).Encode(map[string]any{"user_id": userID, "absences": dtos})
+}
+
+func (h *Handler) cancelAbsence(w http.ResponseWriter, r *http.Request) {
+	var req CancelAbsenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+	if req.AbsenceID == 0 {
+		writeError(w, r, "INVALID_REQUEST", "absence_id is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	absence, err := h.service.CancelAbsence(r.Context(), req.AbsenceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrAbsenceNotFound):
+			writeError(w, r, "NOT_FOUND", "absence not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrUserNotFound):
+			writeError(w, r, "NOT_FOUND", "user not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"absence": fromDomainAbsence(absence)})
 }
 
 func (h *Handler) createPullRequest(w http.ResponseWriter, r *http.Request) {
 	var req CreatePullRequestRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
 		return
 	}
 
-	pr, err := h.service.CreatePullRequest(r.Context(), req.ID, req.Name, req.AuthorID)
+	pr, err := h.service.CreatePullRequest(r.Context(), req.ID, req.Name, req.AuthorID, req.TeamName, req.ChangedFiles, req.Draft)
 	if err != nil {
 		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
 		case errors.Is(err, app.ErrAuthorNotFound):
-			writeError(w, "NOT_FOUND", "author or author's team not found", http.StatusNotFound, err)
+			writeError(w, r, "NOT_FOUND", "author or author's team not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrNotTeamMember):
+			writeError(w, r, "INVALID_REQUEST", "author is not a member of the team", http.StatusBadRequest, err)
 		case errors.Is(err, app.ErrPRExists):
-			writeError(w, "PR_EXISTS", "PR id already exists", http.StatusConflict, err)
+			writeError(w, r, "PR_EXISTS", "PR id already exists", http.StatusConflict, err)
 		default:
-			writeError(w, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
 		}
 		return
 	}
 
+	setETag(w, pr)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]any{"pr": fromDomainPR(pr)})
 }
 
+func (h *Handler) getPullRequest(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		writeError(w, r, "INVALID_REQUEST", "pull_request_id is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	pr, err := h.service.GetPullRequest(r.Context(), prID)
+	if err != nil {
+		if errors.Is(err, app.ErrPRNotFound) {
+			writeError(w, r, "NOT_FOUND", "pull request not found", http.StatusNotFound, err)
+			return
+		}
+		writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(w, pr)
+	if notModified(r, pr) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"pr": fromDomainPR(pr)})
+}
+
+func (h *Handler) getPullRequestHistory(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		writeError(w, r, "INVALID_REQUEST", "pull_request_id is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	events, err := h.service.GetPullRequestHistory(r.Context(), prID)
+	if err != nil {
+		if errors.Is(err, app.ErrPRNotFound) {
+			writeError(w, r, "NOT_FOUND", "pull request not found", http.StatusNotFound, err)
+			return
+		}
+		writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		return
+	}
+
+	dtos := make([]PREventDTO, len(events))
+	for i, event := range events {
+		dtos[i] = fromDomainPREvent(event)
+	}
+	response := map[string]any{
+		"pull_request_id": prID,
+		"events":          dtos,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) listPullRequests(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params, err := parseListParams(query)
+	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+	mergedFrom, err := parseTimeParam(query, "merged_from")
+	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+	mergedTo, err := parseTimeParam(query, "merged_to")
+	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.service.ListPullRequests(r.Context(), domain.PRFilter{
+		AuthorID:     query.Get("author_id"),
+		TeamName:     query.Get("team_name"),
+		Statuses:     params.Statuses,
+		ReviewerID:   query.Get("reviewer_id"),
+		NameContains: query.Get("name"),
+		CreatedFrom:  params.CreatedFrom,
+		CreatedTo:    params.CreatedTo,
+		MergedFrom:   mergedFrom,
+		MergedTo:     mergedTo,
+		Sort:         params.Sort,
+		After:        params.After,
+		Limit:        params.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, app.ErrInvalidListFilter) {
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+			return
+		}
+		writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		return
+	}
+
+	prDTOs := make([]PullRequestDTO, 0, len(page.PullRequests))
+	for _, pr := range page.PullRequests {
+		prDTOs = append(prDTOs, fromDomainPR(pr))
+	}
+
+	response := map[string]any{"pull_requests": prDTOs}
+	if page.Next != nil {
+		response["next_cursor"] = encodeCursor(page.Next)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 func (h *Handler) mergePullRequest(w http.ResponseWriter, r *http.Request) {
 	var req MergePullRequestRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
 		return
 	}
 
-	pr, err := h.service.MergePullRequest(r.Context(), req.PullRequestID)
+	ifMatch, err := ifMatchVersion(r)
 	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+
+	pr, err := h.service.MergePullRequest(r.Context(), req.PullRequestID, req.Force, ifMatch)
+	if err != nil {
+		var blockedErr *app.ErrMergeBlocked
+		var transitionErr *app.ErrInvalidTransition
 		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
 		case errors.Is(err, app.ErrPRNotFound):
-			writeError(w, "NOT_FOUND", "pull request not found", http.StatusNotFound, err)
-		case errors.Is(err, app.ErrPRMerged):
-			writeError(w, "PR_ALREADY_MERGED", "pull request is already merged", http.StatusConflict, err)
+			writeError(w, r, "NOT_FOUND", "pull request not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrAuthorNotFound):
+			writeError(w, r, "NOT_FOUND", "author or author's team not found", http.StatusNotFound, err)
+		case errors.As(err, &blockedErr):
+			writeError(w, r, "MERGE_BLOCKED", blockedErr.Error(), http.StatusConflict, err)
+		case errors.As(err, &transitionErr):
+			writeError(w, r, "INVALID_TRANSITION", transitionErr.Error(), http.StatusConflict, err)
+		case errors.Is(err, app.ErrVersionMismatch):
+			writeError(w, r, "VERSION_CONFLICT", err.Error(), http.StatusPreconditionFailed, err)
+		case errors.Is(err, app.ErrVersionConflict):
+			writeError(w, r, "VERSION_CONFLICT", err.Error(), http.StatusConflict, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	setETag(w, pr)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"pr": fromDomainPR(pr)})
+}
+
+func (h *Handler) markReady(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.service.MarkReady)
+}
+
+func (h *Handler) closePullRequest(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.service.ClosePullRequest)
+}
+
+func (h *Handler) reopenPullRequest(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.service.ReopenPullRequest)
+}
+
+// changeStatus - общий обработчик переходов PR между статусами.
+func (h *Handler) changeStatus(w http.ResponseWriter, r *http.Request,
+	transition func(ctx context.Context, prID string, ifMatch int) (*domain.PullRequest, error)) {
+	var req PullRequestActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+
+	ifMatch, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+
+	pr, err := transition(r.Context(), req.PullRequestID, ifMatch)
+	if err != nil {
+		var transitionErr *app.ErrInvalidTransition
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrPRNotFound):
+			writeError(w, r, "NOT_FOUND", "pull request not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrAuthorNotFound):
+			writeError(w, r, "NOT_FOUND", "author or author's team not found", http.StatusNotFound, err)
+		case errors.As(err, &transitionErr):
+			writeError(w, r, "INVALID_TRANSITION", transitionErr.Error(), http.StatusConflict, err)
+		case errors.Is(err, app.ErrVersionMismatch):
+			writeError(w, r, "VERSION_CONFLICT", err.Error(), http.StatusPreconditionFailed, err)
+		case errors.Is(err, app.ErrVersionConflict):
+			writeError(w, r, "VERSION_CONFLICT", err.Error(), http.StatusConflict, err)
 		default:
-			writeError(w, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
 		}
 		return
 	}
 
+	setETag(w, pr)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]any{"pr": fromDomainPR(pr)})
 }
@@ -131,27 +672,42 @@ func (h *Handler) mergePullRequest(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) reassignReviewer(w http.ResponseWriter, r *http.Request) {
 	var req ReassignReviewerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
 		return
 	}
 
-	pr, newReviewerID, err := h.service.ReassignReviewer(r.Context(), req.PullRequestID, req.OldReviewerID)
+	ifMatch, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+
+	pr, newReviewerID, err := h.service.ReassignReviewer(r.Context(), req.PullRequestID, req.OldReviewerID, ifMatch)
 	if err != nil {
 		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
 		case errors.Is(err, app.ErrPRNotFound):
-			writeError(w, "NOT_FOUND", "pull request not found", http.StatusNotFound, err)
+			writeError(w, r, "NOT_FOUND", "pull request not found", http.StatusNotFound, err)
 		case errors.Is(err, app.ErrPRMerged):
-			writeError(w, "PR_MERGED", err.Error(), http.StatusConflict, err)
+			writeError(w, r, "PR_MERGED", err.Error(), http.StatusConflict, err)
+		case errors.Is(err, app.ErrPRNotOpen):
+			writeError(w, r, "PR_NOT_OPEN", err.Error(), http.StatusConflict, err)
 		case errors.Is(err, app.ErrReviewerNotAssigned):
-			writeError(w, "NOT_ASSIGNED", err.Error(), http.StatusConflict, err)
+			writeError(w, r, "NOT_ASSIGNED", err.Error(), http.StatusConflict, err)
 		case errors.Is(err, app.ErrNoCandidates):
-			writeError(w, "NO_CANDIDATE", err.Error(), http.StatusConflict, err)
+			writeError(w, r, "NO_CANDIDATE", err.Error(), http.StatusConflict, err)
+		case errors.Is(err, app.ErrVersionMismatch):
+			writeError(w, r, "VERSION_CONFLICT", err.Error(), http.StatusPreconditionFailed, err)
+		case errors.Is(err, app.ErrVersionConflict):
+			writeError(w, r, "VERSION_CONFLICT", err.Error(), http.StatusConflict, err)
 		default:
-			writeError(w, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
 		}
 		return
 	}
 
+	setETag(w, pr)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]any{
 		"pr":          fromDomainPR(pr),
@@ -159,21 +715,96 @@ func (h *Handler) reassignReviewer(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) submitReview(w http.ResponseWriter, r *http.Request) {
+	var req SubmitReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+		return
+	}
+
+	ifMatch, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+
+	pr, err := h.service.SubmitReview(r.Context(), req.PullRequestID, req.ReviewerID, domain.ReviewState(req.State), ifMatch)
+	if err != nil {
+		var transitionErr *app.ErrInvalidReviewTransition
+		switch {
+		case errors.Is(err, app.ErrForbidden):
+			writeError(w, r, "FORBIDDEN", err.Error(), http.StatusForbidden, err)
+		case errors.Is(err, app.ErrInvalidReviewState):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, app.ErrPRNotFound):
+			writeError(w, r, "NOT_FOUND", "pull request not found", http.StatusNotFound, err)
+		case errors.Is(err, app.ErrPRMerged):
+			writeError(w, r, "PR_MERGED", err.Error(), http.StatusConflict, err)
+		case errors.Is(err, app.ErrPRNotOpen):
+			writeError(w, r, "PR_NOT_OPEN", err.Error(), http.StatusConflict, err)
+		case errors.Is(err, app.ErrReviewerNotAssigned):
+			writeError(w, r, "NOT_ASSIGNED", err.Error(), http.StatusConflict, err)
+		case errors.As(err, &transitionErr):
+			writeError(w, r, "INVALID_TRANSITION", transitionErr.Error(), http.StatusConflict, err)
+		case errors.Is(err, app.ErrVersionMismatch):
+			writeError(w, r, "VERSION_CONFLICT", err.Error(), http.StatusPreconditionFailed, err)
+		case errors.Is(err, app.ErrVersionConflict):
+			writeError(w, r, "VERSION_CONFLICT", err.Error(), http.StatusConflict, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	setETag(w, pr)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"pr": fromDomainPR(pr)})
+}
+
 func (h *Handler) getReviews(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		writeError(w, "INVALID_REQUEST", "user_id is required", http.StatusBadRequest, nil)
+		writeError(w, r, "INVALID_REQUEST", "user_id is required", http.StatusBadRequest, nil)
 		return
 	}
 
-	prs, err := h.service.GetPullRequestsByReviewer(r.Context(), userID)
+	pendingOnly := false
+	if raw := r.URL.Query().Get("pending"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, r, "INVALID_REQUEST", "pending must be a boolean", http.StatusBadRequest, err)
+			return
+		}
+		pendingOnly = parsed
+	}
+	params, err := parseListParams(r.URL.Query())
 	if err != nil {
-		writeError(w, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
 		return
 	}
 
-	prDTOs := make([]PullRequestShortDTO, 0, len(prs))
-	for _, pr := range prs {
+	page, err := h.service.ListReviewerPullRequests(r.Context(), domain.ReviewerPRFilter{
+		ReviewerID:  userID,
+		Statuses:    params.Statuses,
+		PendingOnly: pendingOnly,
+		AuthorID:    r.URL.Query().Get("author_id"),
+		CreatedFrom: params.CreatedFrom,
+		CreatedTo:   params.CreatedTo,
+		Sort:        params.Sort,
+		After:       params.After,
+		Limit:       params.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, app.ErrInvalidListFilter) {
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+			return
+		}
+		writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		return
+	}
+
+	prDTOs := make([]PullRequestShortDTO, 0, len(page.PullRequests))
+	for _, pr := range page.PullRequests {
 		prDTOs = append(prDTOs, fromDomainPRtoShort(pr))
 	}
 
@@ -181,14 +812,23 @@ func (h *Handler) getReviews(w http.ResponseWriter, r *http.Request) {
 		"user_id":       userID,
 		"pull_requests": prDTOs,
 	}
+	if page.Next != nil {
+		response["next_cursor"] = encodeCursor(page.Next)
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
-func writeError(w http.ResponseWriter, code, message string, httpStatus int, err error) {
+// writeError пишет ответ с ошибкой. Причина err попадает в лог запроса: для ответов 5xx как ошибка,
+// для остальных - как отказ в обработке.
+func writeError(w http.ResponseWriter, r *http.Request, code, message string, httpStatus int, err error) {
 	if err != nil {
-		log.Printf("ERROR: %v", err)
+		level := slog.LevelInfo
+		if httpStatus >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(r.Context(), level, "request failed", "code", code, "error", err)
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(httpStatus)
@@ -200,6 +840,62 @@ func writeError(w http.ResponseWriter, code, message string, httpStatus int, err
 	})
 }
 
+func (h *Handler) getReviewerStats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := h.service.ReviewerStats(r.Context(), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrInvalidStatsPeriod):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	dtos := make([]ReviewerStatsDTO, len(stats))
+	for i, st := range stats {
+		dtos[i] = fromDomainReviewerStats(st)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"reviewers": dtos})
+}
+
+func (h *Handler) getTeamStats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := h.service.TeamStats(r.Context(), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, app.ErrInvalidStatsPeriod):
+			writeError(w, r, "INVALID_REQUEST", err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, app.ErrNotFound):
+			writeError(w, r, "NOT_FOUND", "team not found", http.StatusNotFound, err)
+		default:
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	dtos := make([]TeamStatsDTO, len(stats))
+	for i, st := range stats {
+		dtos[i] = fromDomainTeamStats(st)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"teams": dtos})
+}
+
 func (h *Handler) setContentTypeJSON(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
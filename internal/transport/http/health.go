@@ -0,0 +1,24 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/wsppppp/manage-pull-request/internal/health"
+)
+
+// healthz - проверка живости: процесс запущен и обрабатывает запросы. Зависимости не проверяются,
+// чтобы недоступность базы не приводила к перезапуску сервиса.
+func (h *Handler) healthz(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{"status": health.StatusOK})
+}
+
+// readyz - проверка готовности: зависимости доступны и сервис не завершается.
+// При неготовности отвечает 503, чтобы балансировщик перестал направлять сюда трафик.
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Ready(r.Context())
+	if !report.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotentBodyBytes    = 1 << 20
+	// maxIdempotencyKeyLength оставляет место для префикса токена в колонке VARCHAR(255).
+	maxIdempotencyKeyLength = 200
+
+	// idempotencyKeyTTL - сколько хранится ответ на запрос с Idempotency-Key.
+	idempotencyKeyTTL = 24 * time.Hour
+	// idempotencyReservationTTL - через сколько ключ без ответа (например, после падения процесса)
+	// может занять новый запрос.
+	idempotencyReservationTTL = time.Minute
+	// idempotencyCleanupInterval - как часто удаляются ключи старше idempotencyKeyTTL.
+	idempotencyCleanupInterval = time.Hour
+)
+
+// IdempotencyStore хранит ответы на запросы с заголовком Idempotency-Key.
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, key, requestHash string, staleBefore, expiredBefore time.Time) (*domain.IdempotencyRecord, bool, error)
+	SaveIdempotencyResponse(ctx context.Context, response domain.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+}
+
+// idempotency повторно отдает сохраненный ответ на POST-запрос с тем же Idempotency-Key от того же токена.
+// Вместе с телом повторяются Content-Type и ETag исходного ответа.
+// Ключ с другим телом запроса отклоняется. Ответы 5xx не сохраняются, чтобы запрос можно было повторить.
+// Ответ хранится idempotencyKeyTTL, а ключ без ответа освобождается через idempotencyReservationTTL.
+func (h *Handler) idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(w, r, "INVALID_REQUEST",
+				fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength),
+				http.StatusBadRequest, nil)
+			return
+		}
+		key = idempotencyScope(r) + key
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			writeError(w, r, "INVALID_REQUEST", "invalid request body", http.StatusBadRequest, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(r, body)
+		now := time.Now()
+		record, reserved, err := h.idempotencyStore.ReserveIdempotencyKey(r.Context(), key, hash,
+			now.Add(-idempotencyReservationTTL), now.Add(-idempotencyKeyTTL))
+		if err != nil {
+			writeError(w, r, "INTERNAL_ERROR", "internal server error", http.StatusInternalServerError, err)
+			return
+		}
+		if !reserved {
+			switch {
+			case record.RequestHash != hash:
+				writeError(w, r, "IDEMPOTENCY_KEY_REUSED", "idempotency key was used with a different request",
+					http.StatusUnprocessableEntity, nil)
+			case !record.Completed():
+				writeError(w, r, "REQUEST_IN_PROGRESS", "request with this idempotency key is in progress",
+					http.StatusConflict, nil)
+			default:
+				w.Header().Set(idempotencyReplayedHeader, "true")
+				if record.ContentType != "" {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				if record.ETag != "" {
+					w.Header().Set("ETag", record.ETag)
+				}
+				w.WriteHeader(record.StatusCode)
+				w.Write(record.Body)
+			}
+			return
+		}
+
+		// Сохраняем результат даже если клиент уже отключился.
+		storeCtx := context.WithoutCancel(r.Context())
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := h.idempotencyStore.ReleaseIdempotencyKey(storeCtx, key); err != nil {
+				slog.ErrorContext(storeCtx, "release idempotency key", "error", err)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+		response := domain.IdempotencyRecord{
+			Key:         key,
+			StatusCode:  rec.status,
+			ContentType: w.Header().Get("Content-Type"),
+			ETag:        w.Header().Get("ETag"),
+			Body:        rec.body.Bytes(),
+		}
+		if err := h.idempotencyStore.SaveIdempotencyResponse(storeCtx, response); err != nil {
+			slog.ErrorContext(storeCtx, "save idempotency response", "error", err)
+			return
+		}
+		saved = true
+	})
+}
+
+// CleanupIdempotencyKeys раз в idempotencyCleanupInterval удаляет ключи старше idempotencyKeyTTL,
+// пока не отменен ctx.
+func (h *Handler) CleanupIdempotencyKeys(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := h.idempotencyStore.DeleteExpiredIdempotencyKeys(ctx, time.Now().Add(-idempotencyKeyTTL))
+			if err != nil {
+				slog.ErrorContext(ctx, "delete expired idempotency keys", "error", err)
+				continue
+			}
+			slog.DebugContext(ctx, "expired idempotency keys deleted", "count", deleted)
+		}
+	}
+}
+
+// requestHash считает отпечаток запроса по методу, пути и телу.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+"\n"+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder пропускает ответ клиенту, запоминая статус и тело.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
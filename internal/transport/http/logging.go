@@ -0,0 +1,94 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/wsppppp/manage-pull-request/internal/logging"
+)
+
+// loggedIDs - параметры запроса, которые попадают в каждую запись лога запроса.
+var loggedIDs = []string{"user_id", "pull_request_id", "team_name", "author_id", "reviewer_id", "old_reviewer_id"}
+
+// routeValue откладывает чтение шаблона маршрута до момента записи: chi узнает его только после сопоставления.
+type routeValue struct {
+	rctx *chi.Context
+}
+
+func (v routeValue) LogValue() slog.Value {
+	if v.rctx == nil || v.rctx.RoutePattern() == "" {
+		return slog.StringValue(unmatchedRoute)
+	}
+	return slog.StringValue(v.rctx.RoutePattern())
+}
+
+// logging начинает запрос в логе с request_id, маршрутом и ID пользователя, PR и команды из параметров
+// или JSON-тела запроса, а после обработки пишет итоговую запись с кодом ответа и длительностью.
+func (h *Handler) logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := logging.WithRequest(r.Context(),
+			"request_id", middleware.GetReqID(r.Context()),
+			"method", r.Method,
+			"path", r.URL.Path,
+			slog.Any("route", routeValue{chi.RouteContext(r.Context())}),
+		)
+		logging.AddAttrs(ctx, requestIDs(r)...)
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		slog.InfoContext(ctx, "request completed",
+			"status", status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"bytes", ww.BytesWritten(),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+// requestIDs возвращает пары ключ-значение loggedIDs из параметров запроса и его JSON-тела.
+// Тело читается с ограничением и возвращается в запрос нетронутым.
+func requestIDs(r *http.Request) []any {
+	var args []any
+	query := r.URL.Query()
+	for _, key := range loggedIDs {
+		if value := query.Get(key); value != "" {
+			args = append(args, key, value)
+		}
+	}
+	if r.Body == nil || r.Method != http.MethodPost {
+		return args
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodyBytes))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return args
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return args
+	}
+	for _, key := range loggedIDs {
+		var value string
+		if json.Unmarshal(fields[key], &value) == nil && value != "" {
+			args = append(args, key, value)
+		}
+	}
+	return args
+}
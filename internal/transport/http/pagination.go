@@ -0,0 +1,114 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wsppppp/manage-pull-request/internal/domain"
+)
+
+var errInvalidCursor = errors.New("cursor is malformed")
+
+// listParams - общие параметры запросов списков PR.
+type listParams struct {
+	Statuses    []domain.PRStatus
+	Sort        domain.PRSort
+	Limit       int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	After       *domain.PRCursor
+}
+
+// parseListParams разбирает параметры status (через запятую), sort, limit, created_from, created_to и cursor.
+// Незаданные параметры остаются нулевыми, значения по умолчанию подставляет сервис.
+func parseListParams(query url.Values) (listParams, error) {
+	var params listParams
+	if raw := query.Get("status"); raw != "" {
+		for _, status := range strings.Split(raw, ",") {
+			params.Statuses = append(params.Statuses, domain.PRStatus(strings.ToUpper(strings.TrimSpace(status))))
+		}
+	}
+	params.Sort = domain.PRSort(query.Get("sort"))
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return listParams{}, errors.New("limit must be a positive integer")
+		}
+		params.Limit = limit
+	}
+	var err error
+	if params.CreatedFrom, err = parseTimeParam(query, "created_from"); err != nil {
+		return listParams{}, err
+	}
+	if params.CreatedTo, err = parseTimeParam(query, "created_to"); err != nil {
+		return listParams{}, err
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil {
+			return listParams{}, err
+		}
+		params.After = cursor
+	}
+	return params, nil
+}
+
+// parseTimeParam разбирает необязательный параметр запроса со временем в формате RFC 3339.
+func parseTimeParam(query url.Values, name string) (*time.Time, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &at, nil
+}
+
+// parseStatsFilter разбирает параметры team_name, from и to запросов статистики.
+func parseStatsFilter(query url.Values) (domain.StatsFilter, error) {
+	from, err := parseTimeParam(query, "from")
+	if err != nil {
+		return domain.StatsFilter{}, err
+	}
+	to, err := parseTimeParam(query, "to")
+	if err != nil {
+		return domain.StatsFilter{}, err
+	}
+	return domain.StatsFilter{TeamName: query.Get("team_name"), From: from, To: to}, nil
+}
+
+// cursorPayload - содержимое непрозрачного курсора страницы.
+type cursorPayload struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// encodeCursor кодирует позицию в списке в непрозрачную строку. Для последней страницы возвращает "".
+func encodeCursor(cursor *domain.PRCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	payload, _ := json.Marshal(cursorPayload{CreatedAt: cursor.CreatedAt, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// decodeCursor разбирает курсор, выданный encodeCursor.
+func decodeCursor(raw string) (*domain.PRCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID == "" {
+		return nil, errInvalidCursor
+	}
+	return &domain.PRCursor{CreatedAt: payload.CreatedAt, ID: payload.ID}, nil
+}
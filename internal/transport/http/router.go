@@ -14,33 +14,79 @@ func (h *Handler) NewRouter() http.Handler {
 	// middleware
 	r.Use(middleware.RequestID) // Добавляет ID каждому запросу
 	r.Use(middleware.RealIP)    // Определяет реальный IP клиента
-	r.Use(middleware.Logger)    // Логирует запросы
+	r.Use(h.metrics)            // Считает запросы и их длительность
+	r.Use(h.logging)            // Логирует запросы с request_id и ID из параметров
 	r.Use(middleware.Recoverer) // Восстанавливается после паник
 	r.Use(h.setContentTypeJSON) // Устанавливает Content-Type: application/json
 
-	// Группа роутов для команд
-	r.Route("/team", func(r chi.Router) {
-		r.Post("/add", h.createTeam)
-		r.Get("/get", h.getTeam)
-	})
+	// API доступно только с токеном
+	r.Group(func(r chi.Router) {
+		r.Use(h.authenticate) // Проверяет токен из Authorization: Bearer
 
-	// Группа роутов для пользователей
-	r.Route("/users", func(r chi.Router) {
-		r.Post("/setIsActive", h.setUserActivity)
-		r.Get("/getReview", h.getReviews)
-	})
+		// Группа роутов для токенов API. Ответ на /token/create содержит секрет,
+		// поэтому эти роуты не проходят через идемпотентность и их ответы не сохраняются.
+		r.Route("/token", func(r chi.Router) {
+			r.Post("/create", h.createAPIToken)
+			r.Get("/list", h.listAPITokens)
+			r.Post("/revoke", h.revokeAPIToken)
+			r.Get("/me", h.getCurrentAPIToken)
+		})
 
-	// Группа роутов для pr
-	r.Route("/pullRequest", func(r chi.Router) {
-		r.Post("/create", h.createPullRequest)
-		r.Post("/reassign", h.reassignReviewer)
-		r.Post("/merge", h.mergePullRequest)
-	})
+		// ключи идемпотентности отделены по токенам
+		r.Group(func(r chi.Router) {
+			r.Use(h.idempotency) // Повторяет сохраненный ответ для Idempotency-Key
+
+			// Группа роутов для команд
+			r.Route("/team", func(r chi.Router) {
+				r.Post("/add", h.createTeam)
+				r.Get("/get", h.getTeam)
+				r.Post("/settings", h.updateTeamSettings)
+				r.Post("/setReviewerPools", h.setReviewerPools)
+				r.Post("/setCodeOwners", h.setCodeOwners)
+				r.Post("/addMember", h.addTeamMember)
+				r.Post("/removeMember", h.removeTeamMember)
+				r.Post("/rename", h.renameTeam)
+				r.Post("/delete", h.deleteTeam)
+			})
 
-	// хэлс-чек
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(`{"status": "Service is running!"}`))
+			// Группа роутов для пользователей
+			r.Route("/users", func(r chi.Router) {
+				r.Post("/setIsActive", h.setUserActivity)
+				r.Post("/deactivate", h.deactivateUsers)
+				r.Post("/addAbsence", h.addAbsence)
+				r.Get("/getAbsences", h.getAbsences)
+				r.Post("/cancelAbsence", h.cancelAbsence)
+				r.Get("/getReview", h.getReviews)
+			})
+
+			// Группа роутов для pr
+			r.Route("/pullRequest", func(r chi.Router) {
+				r.Get("/get", h.getPullRequest)
+				r.Get("/history", h.getPullRequestHistory)
+				r.Get("/list", h.listPullRequests)
+				r.Post("/create", h.createPullRequest)
+				r.Post("/reassign", h.reassignReviewer)
+				r.Post("/merge", h.mergePullRequest)
+				r.Post("/review", h.submitReview)
+				r.Post("/ready", h.markReady)
+				r.Post("/close", h.closePullRequest)
+				r.Post("/reopen", h.reopenPullRequest)
+			})
+
+			// Группа роутов для статистики
+			r.Route("/stats", func(r chi.Router) {
+				r.Get("/reviewers", h.getReviewerStats)
+				r.Get("/teams", h.getTeamStats)
+			})
+		})
 	})
 
+	// метрики Prometheus
+	r.Method(http.MethodGet, "/metrics", metricsHandler())
+
+	// проверки живости и готовности
+	r.Get("/healthz", h.healthz)
+	r.Get("/readyz", h.readyz)
+
 	return r
 }
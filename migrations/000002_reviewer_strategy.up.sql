@@ -0,0 +1,5 @@
+-- стратегия выбора ревьюеров для команды
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS reviewer_strategy VARCHAR(32) NOT NULL DEFAULT 'random';
+
+-- вес пользователя для взвешенного выбора ревьюеров
+ALTER TABLE users ADD COLUMN IF NOT EXISTS review_weight INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+-- минимальное и максимальное число ревьюеров в команде
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS min_reviewers INTEGER NOT NULL DEFAULT 2;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS max_reviewers INTEGER NOT NULL DEFAULT 2;
+ALTER TABLE teams ADD CONSTRAINT chk_teams_reviewers_range
+    CHECK (min_reviewers >= 0 AND max_reviewers >= min_reviewers);
+
+-- требуемое число ревьюеров, зафиксированное при создании pr
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS required_reviewers INTEGER NOT NULL DEFAULT 0;
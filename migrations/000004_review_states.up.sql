@@ -0,0 +1,8 @@
+-- тип для состояния ревью
+CREATE TYPE review_state AS ENUM ('PENDING', 'APPROVED', 'CHANGES_REQUESTED', 'DISMISSED');
+
+-- состояние ревью каждого назначенного ревьюера
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS review_state review_state NOT NULL DEFAULT 'PENDING';
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer_id ON pr_reviewers(reviewer_id);
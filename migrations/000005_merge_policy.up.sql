@@ -0,0 +1,6 @@
+-- политика слияния pr в команде
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS merge_policy VARCHAR(32) NOT NULL DEFAULT 'none';
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 0;
+
+-- отметка о принудительном слиянии в обход политики
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS force_merged BOOLEAN NOT NULL DEFAULT FALSE;
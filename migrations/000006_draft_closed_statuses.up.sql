@@ -0,0 +1,6 @@
+-- новые статусы pr: черновик и закрытый без слияния
+ALTER TYPE pr_status ADD VALUE IF NOT EXISTS 'DRAFT';
+ALTER TYPE pr_status ADD VALUE IF NOT EXISTS 'CLOSED';
+
+-- время закрытия pr
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
@@ -0,0 +1,8 @@
+-- сохраненные ответы на запросы с заголовком Idempotency-Key
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    status_code INTEGER, -- NULL, пока исходный запрос обрабатывается
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
@@ -0,0 +1,2 @@
+-- версия pr для оптимистичной блокировки, увеличивается при каждом изменении
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+-- журнал событий pr: только добавление записей
+CREATE TABLE IF NOT EXISTS pr_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    pr_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    event_type VARCHAR(64) NOT NULL,
+    actor_id VARCHAR(255),
+    reviewer_id VARCHAR(255),
+    old_reviewer_id VARCHAR(255),
+    details TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_pr_events_pr_id ON pr_events(pr_id, event_id);
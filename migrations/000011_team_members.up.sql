@@ -0,0 +1,22 @@
+-- участники команд: пользователь может состоять в нескольких командах
+CREATE TABLE IF NOT EXISTS team_members (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (team_name, user_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members(user_id);
+
+-- переносим текущее членство; users.team_name остается основной командой пользователя
+INSERT INTO team_members (team_name, user_id)
+SELECT team_name, user_id FROM users WHERE team_name IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- команда, из которой назначаются ревьюеры pr
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS team_name VARCHAR(255)
+    REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL;
+
+UPDATE pull_requests pr SET team_name = u.team_name
+FROM users u WHERE u.user_id = pr.author_id AND pr.team_name IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pr_team_name ON pull_requests(team_name);
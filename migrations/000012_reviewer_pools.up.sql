@@ -0,0 +1,16 @@
+-- дополнительные пулы ревьюеров команды: другая команда или явный список пользователей
+CREATE TABLE IF NOT EXISTS reviewer_pools (
+    id BIGSERIAL PRIMARY KEY,
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE,
+    mode VARCHAR(20) NOT NULL CHECK (mode IN ('always', 'fallback')),
+    source_team VARCHAR(255) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_reviewer_pools_team_name ON reviewer_pools(team_name);
+
+-- пользователи пулов, заданных явным списком
+CREATE TABLE IF NOT EXISTS reviewer_pool_users (
+    pool_id BIGINT NOT NULL REFERENCES reviewer_pools(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (pool_id, user_id)
+    );
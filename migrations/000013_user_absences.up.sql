@@ -0,0 +1,12 @@
+-- периоды отсутствия пользователей: в это время они не назначаются ревьюерами
+CREATE TABLE IF NOT EXISTS user_absences (
+    absence_id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_user_absences_user_id ON user_absences(user_id, ends_at);
@@ -0,0 +1,13 @@
+-- время назначения ревьюера для статистики нагрузки
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- у существующих назначений берем время последнего события назначения, без него - время создания PR
+UPDATE pr_reviewers r
+SET assigned_at = COALESCE(
+        (SELECT MAX(e.created_at) FROM pr_events e
+         WHERE e.pr_id = r.pr_id AND e.reviewer_id = r.reviewer_id
+           AND e.event_type IN ('reviewer_assigned', 'reviewer_replaced')),
+        (SELECT pr.created_at FROM pull_requests pr WHERE pr.pull_request_id = r.pr_id));
+
+-- замены ревьюера для статистики
+CREATE INDEX IF NOT EXISTS idx_pr_events_old_reviewer ON pr_events(old_reviewer_id) WHERE old_reviewer_id IS NOT NULL;
@@ -0,0 +1,13 @@
+-- токены API: хранится только SHA-256 секрета
+CREATE TABLE IF NOT EXISTS api_tokens (
+    token_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(32) NOT NULL CHECK (role IN ('admin', 'team-lead', 'member', 'bot')),
+    user_id VARCHAR(255) REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+    );
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
@@ -0,0 +1,3 @@
+-- ответы /token/create содержали секрет токена и попадали в idempotency_keys; удаляем их
+DELETE FROM idempotency_keys
+WHERE position(convert_to('"secret":"mpr_', 'UTF8') IN response_body) > 0;
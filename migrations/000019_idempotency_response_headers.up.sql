@@ -0,0 +1,3 @@
+-- заголовки сохраненного ответа, которые повторяются вместе с телом
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS content_type VARCHAR(255);
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS etag VARCHAR(255);
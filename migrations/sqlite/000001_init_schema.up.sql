@@ -0,0 +1,72 @@
+-- схема для SQLite, соответствует миграциям PostgreSQL 000001-000009
+
+-- таблица команд
+CREATE TABLE IF NOT EXISTS teams (
+    team_name TEXT PRIMARY KEY,
+    reviewer_strategy TEXT NOT NULL DEFAULT 'random',
+    min_reviewers INTEGER NOT NULL DEFAULT 2,
+    max_reviewers INTEGER NOT NULL DEFAULT 2,
+    merge_policy TEXT NOT NULL DEFAULT 'none',
+    required_approvals INTEGER NOT NULL DEFAULT 0,
+    CHECK (min_reviewers >= 0 AND max_reviewers >= min_reviewers)
+    );
+
+-- таблица пользователей
+CREATE TABLE IF NOT EXISTS users (
+    user_id TEXT PRIMARY KEY,
+    username TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    team_name TEXT REFERENCES teams(team_name) ON DELETE SET NULL,
+    review_weight INTEGER NOT NULL DEFAULT 1
+    );
+
+-- таблица для pr, статусы вместо enum проверяются ограничением
+CREATE TABLE IF NOT EXISTS pull_requests (
+    pull_request_id TEXT PRIMARY KEY,
+    pull_request_name TEXT NOT NULL,
+    author_id TEXT NOT NULL REFERENCES users(user_id),
+    status TEXT NOT NULL DEFAULT 'OPEN' CHECK (status IN ('DRAFT', 'OPEN', 'MERGED', 'CLOSED')),
+    required_reviewers INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    merged_at TIMESTAMP,
+    closed_at TIMESTAMP,
+    force_merged BOOLEAN NOT NULL DEFAULT FALSE,
+    version INTEGER NOT NULL DEFAULT 1
+    );
+
+-- связующая таблица для ревьюеров PR с состоянием их ревью
+CREATE TABLE IF NOT EXISTS pr_reviewers (
+    pr_id TEXT REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    reviewer_id TEXT REFERENCES users(user_id) ON DELETE CASCADE,
+    review_state TEXT NOT NULL DEFAULT 'PENDING'
+        CHECK (review_state IN ('PENDING', 'APPROVED', 'CHANGES_REQUESTED', 'DISMISSED')),
+    reviewed_at TIMESTAMP,
+    PRIMARY KEY (pr_id, reviewer_id)
+    );
+
+-- сохраненные ответы на запросы с заголовком Idempotency-Key
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER, -- NULL, пока исходный запрос обрабатывается
+    response_body BLOB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- журнал событий pr: только добавление записей
+CREATE TABLE IF NOT EXISTS pr_events (
+    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pr_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    actor_id TEXT,
+    reviewer_id TEXT,
+    old_reviewer_id TEXT,
+    details TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- индексы
+CREATE INDEX IF NOT EXISTS idx_users_team_name ON users(team_name);
+CREATE INDEX IF NOT EXISTS idx_pr_author_id ON pull_requests(author_id);
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer_id ON pr_reviewers(reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_pr_events_pr_id ON pr_events(pr_id, event_id);
@@ -0,0 +1,20 @@
+-- участники команд: пользователь может состоять в нескольких командах
+CREATE TABLE IF NOT EXISTS team_members (
+    team_name TEXT NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (team_name, user_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members(user_id);
+
+-- переносим текущее членство; users.team_name остается основной командой пользователя
+INSERT OR IGNORE INTO team_members (team_name, user_id)
+SELECT team_name, user_id FROM users WHERE team_name IS NOT NULL;
+
+-- команда, из которой назначаются ревьюеры pr
+ALTER TABLE pull_requests ADD COLUMN team_name TEXT REFERENCES teams(team_name) ON DELETE SET NULL;
+
+UPDATE pull_requests SET team_name = (SELECT u.team_name FROM users u WHERE u.user_id = pull_requests.author_id)
+WHERE team_name IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pr_team_name ON pull_requests(team_name);
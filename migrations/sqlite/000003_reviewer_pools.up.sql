@@ -0,0 +1,16 @@
+-- дополнительные пулы ревьюеров команды: другая команда или явный список пользователей
+CREATE TABLE IF NOT EXISTS reviewer_pools (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_name TEXT NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    mode TEXT NOT NULL CHECK (mode IN ('always', 'fallback')),
+    source_team TEXT REFERENCES teams(team_name) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_reviewer_pools_team_name ON reviewer_pools(team_name);
+
+-- пользователи пулов, заданных явным списком
+CREATE TABLE IF NOT EXISTS reviewer_pool_users (
+    pool_id INTEGER NOT NULL REFERENCES reviewer_pools(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (pool_id, user_id)
+    );
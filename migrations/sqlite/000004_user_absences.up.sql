@@ -0,0 +1,13 @@
+-- периоды отсутствия пользователей: в это время они не назначаются ревьюерами
+-- время хранится в UTC, чтобы строки сравнивались в хронологическом порядке
+CREATE TABLE IF NOT EXISTS user_absences (
+    absence_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_user_absences_user_id ON user_absences(user_id, ends_at);
@@ -0,0 +1,18 @@
+-- правила владения кодом команды в стиле CODEOWNERS; при совпадении нескольких правил действует последнее
+CREATE TABLE IF NOT EXISTS code_owner_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_name TEXT NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    pattern TEXT NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_code_owner_rules_team_name ON code_owner_rules(team_name);
+
+-- владельцы файлов, подходящих под правило
+CREATE TABLE IF NOT EXISTS code_owner_rule_users (
+    rule_id INTEGER NOT NULL REFERENCES code_owner_rules(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (rule_id, user_id)
+    );
+
+-- пути файлов, измененных в PR (JSON-массив), по ним ищутся владельцы кода
+ALTER TABLE pull_requests ADD COLUMN changed_files TEXT NOT NULL DEFAULT '[]';
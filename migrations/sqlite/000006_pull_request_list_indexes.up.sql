@@ -0,0 +1,10 @@
+-- порядок выдачи списков PR и продолжение с курсора; время сравнивается через julianday,
+-- поэтому индексируется то же выражение
+CREATE INDEX IF NOT EXISTS idx_pr_created_at ON pull_requests(julianday(created_at), pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_pr_status_created_at ON pull_requests(status, julianday(created_at), pull_request_id);
+
+-- фильтр по времени слияния; несмерженные PR в индекс не попадают
+CREATE INDEX IF NOT EXISTS idx_pr_merged_at ON pull_requests(julianday(merged_at)) WHERE merged_at IS NOT NULL;
+
+-- PR ревьюера с учетом состояния его ревью
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer_state ON pr_reviewers(reviewer_id, review_state);
@@ -0,0 +1,3 @@
+-- ответы /token/create содержали секрет токена и попадали в idempotency_keys; удаляем их
+DELETE FROM idempotency_keys
+WHERE CAST(response_body AS TEXT) LIKE '%"secret":"mpr_%';
@@ -0,0 +1,3 @@
+-- заголовки сохраненного ответа, которые повторяются вместе с телом
+ALTER TABLE idempotency_keys ADD COLUMN content_type TEXT;
+ALTER TABLE idempotency_keys ADD COLUMN etag TEXT;
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MigrationsTable - таблица, в которой golang-migrate хранит версию схемы.
+const MigrationsTable = "schema_migrations"
+
+const migrationVersionQuery = "SELECT version, dirty FROM " + MigrationsTable + " LIMIT 1"
+
+// MigrationVersion возвращает версию схемы PostgreSQL и признак незавершенной миграции.
+func MigrationVersion(ctx context.Context, pool *pgxpool.Pool) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	if err := pool.QueryRow(ctx, migrationVersionQuery).Scan(&version, &dirty); err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
+
+// SQLiteMigrationVersion возвращает версию схемы SQLite и признак незавершенной миграции.
+func SQLiteMigrationVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	if err := db.QueryRowContext(ctx, migrationVersionQuery).Scan(&version, &dirty); err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
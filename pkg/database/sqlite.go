@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// NewSQLiteClient открывает файл базы SQLite и проверяет соединение.
+// Пишущие транзакции начинаются с BEGIN IMMEDIATE, а конкурирующие запросы ждут блокировку до busy_timeout,
+// поэтому параллельные изменения выполняются по очереди, как в PostgreSQL.
+func NewSQLiteClient(ctx context.Context, path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate", path)
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	// Проверяем соединение
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping sqlite database: %w", err)
+	}
+
+	return db, nil
+}
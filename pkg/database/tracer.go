@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// queryTracer пишет каждый запрос к PostgreSQL в лог уровня debug с длительностью и ошибкой.
+// Записи делаются с контекстом запроса, поэтому несут его атрибуты (request_id и т.д.).
+type queryTracer struct{}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return ctx
+	}
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, at: time.Now()})
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	args := []any{
+		"sql", start.sql,
+		"duration_ms", float64(time.Since(start.at).Microseconds()) / 1000,
+		"rows", data.CommandTag.RowsAffected(),
+	}
+	if data.Err != nil {
+		args = append(args, "error", data.Err)
+	}
+	slog.DebugContext(ctx, "query", args...)
+}